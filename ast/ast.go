@@ -48,10 +48,11 @@ func (p *Root) String() string {
 	return out.String()
 }
 
-//LetStatement represents a statement in the form of `let <identifier> = <expression>`
+//LetStatement represents a statement in the form of `let <pattern> = <expression>`
+//where the pattern is a name or takes the value apart: `let [a, b] = xs`.
 type LetStatement struct {
 	Token token.Token
-	Name  *IdentifierStatement
+	Name  Pattern
 	Value Expression
 }
 
@@ -142,6 +143,75 @@ func (i *IntegerLiteral) String() string {
 	return i.Token.Literal
 }
 
+//FloatLiteral can be for example, '3.14' or '1e-9'.
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (f *FloatLiteral) expressionNode() {}
+func (f *FloatLiteral) TokenLiteral() string {
+	return f.Token.Literal
+}
+func (f *FloatLiteral) String() string {
+	return f.Token.Literal
+}
+
+//StringLiteral represents a string like "Monkey" or `C:\Monkey`.
+//Value holds the string with its escape sequences already resolved.
+//Raw strings, the ones between backticks or triple backticks, are
+//printed back from their token exactly as they were written.
+type StringLiteral struct {
+	Token token.Token
+	Value string
+	Raw   bool
+}
+
+func (s *StringLiteral) expressionNode() {}
+func (s *StringLiteral) TokenLiteral() string {
+	return s.Token.Literal
+}
+func (s *StringLiteral) String() string {
+	if s.Raw && s.Token.Type == token.RAW_BLOCK {
+		return "```" + s.Token.Literal + "```"
+	}
+	if s.Raw {
+		return "`" + s.Token.Literal + "`"
+	}
+	return quote(s.Value)
+}
+
+//TemplateLiteral represents a string with interpolations like
+//"Hello ${name}, you have ${count + 1} items".
+//Texts holds the pieces of the string around the expressions, so there
+//is always one more text than expressions (which may be empty strings).
+type TemplateLiteral struct {
+	Token       token.Token //the first piece of the string
+	Texts       []string
+	Expressions []Expression
+}
+
+func (t *TemplateLiteral) expressionNode() {}
+func (t *TemplateLiteral) TokenLiteral() string {
+	return t.Token.Literal
+}
+func (t *TemplateLiteral) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(`"`)
+	for i, text := range t.Texts {
+		out.WriteString(escape(text))
+		if i < len(t.Expressions) {
+			out.WriteString("${")
+			out.WriteString(t.Expressions[i].String())
+			out.WriteString("}")
+		}
+	}
+	out.WriteString(`"`)
+
+	return out.String()
+}
+
 //PrefixExpression represents something like '!isInvalid'.
 //It means it has an operator in the left side and an expression in the right side.
 type PrefixExpression struct {
@@ -158,8 +228,8 @@ func (p *PrefixExpression) String() string {
 	return fmt.Sprintf("(%s%s)", p.Operator, p.Right.String())
 }
 
-//InExpression represents something like 'counter++'.
-//It means it has an expression in the left side and an operator in the right side.
+//InExpression represents something like 'a + b'.
+//It means it has an expression in each side of the operator.
 type InfixExpression struct {
 	Token       token.Token
 	Left, Right Expression
@@ -174,6 +244,106 @@ func (inf *InfixExpression) String() string {
 	return fmt.Sprintf("(%s %s %s)", inf.Left.String(), inf.Operator, inf.Right.String())
 }
 
+//PostfixExpression represents something like 'counter++'.
+//It means it has an expression in the left side and an operator in the right side.
+type PostfixExpression struct {
+	Token    token.Token
+	Left     Expression
+	Operator string
+}
+
+func (pf *PostfixExpression) expressionNode() {}
+func (pf *PostfixExpression) TokenLiteral() string {
+	return pf.Token.Literal
+}
+func (pf *PostfixExpression) String() string {
+	return fmt.Sprintf("(%s%s)", pf.Left.String(), pf.Operator)
+}
+
+//LogicalExpression represents 'a && b' and 'a || b'. Unlike an
+//InfixExpression, Right is only evaluated when Left does not already
+//decide the result: when Left is true for '&&' or false for '||'.
+type LogicalExpression struct {
+	Token    token.Token //the operator token
+	Left     Expression
+	Operator string
+	Right    Expression
+}
+
+func (le *LogicalExpression) expressionNode() {}
+func (le *LogicalExpression) TokenLiteral() string {
+	return le.Token.Literal
+}
+func (le *LogicalExpression) String() string {
+	return fmt.Sprintf("(%s %s %s)", le.Left.String(), le.Operator, le.Right.String())
+}
+
+//ConditionalExpression represents 'cond ? a : b', which gives a when
+//cond is true and b otherwise.
+type ConditionalExpression struct {
+	Token       token.Token //the '?' token
+	Condition   Expression
+	Consequence Expression
+	Alternative Expression
+}
+
+func (ce *ConditionalExpression) expressionNode() {}
+func (ce *ConditionalExpression) TokenLiteral() string {
+	return ce.Token.Literal
+}
+func (ce *ConditionalExpression) String() string {
+	return fmt.Sprintf("(%s ? %s : %s)", ce.Condition.String(), ce.Consequence.String(), ce.Alternative.String())
+}
+
+//CoalesceExpression represents 'a ?? b', which gives a unless it is
+//null, and only then evaluates b.
+type CoalesceExpression struct {
+	Token token.Token //the '??' token
+	Left  Expression
+	Right Expression
+}
+
+func (ce *CoalesceExpression) expressionNode() {}
+func (ce *CoalesceExpression) TokenLiteral() string {
+	return ce.Token.Literal
+}
+func (ce *CoalesceExpression) String() string {
+	return fmt.Sprintf("(%s ?? %s)", ce.Left.String(), ce.Right.String())
+}
+
+//AssignExpression represents a new value given to something that
+//already exists, 'x = 5', or one of the compound forms like 'x += 1'.
+//Target is an identifier, an index (arr[0]) or a member (user.name).
+type AssignExpression struct {
+	Token    token.Token //the operator token
+	Target   Expression
+	Operator string
+	Value    Expression
+}
+
+func (ae *AssignExpression) expressionNode() {}
+func (ae *AssignExpression) TokenLiteral() string {
+	return ae.Token.Literal
+}
+func (ae *AssignExpression) String() string {
+	return fmt.Sprintf("(%s %s %s)", ae.Target.String(), ae.Operator, ae.Value.String())
+}
+
+//MemberExpression represents the access to a field like 'user.name'.
+type MemberExpression struct {
+	Token    token.Token //the '.' token
+	Object   Expression
+	Property *IdentifierStatement
+}
+
+func (me *MemberExpression) expressionNode() {}
+func (me *MemberExpression) TokenLiteral() string {
+	return me.Token.Literal
+}
+func (me *MemberExpression) String() string {
+	return fmt.Sprintf("(%s.%s)", me.Object.String(), me.Property.String())
+}
+
 //BooleanLiteral represents boolean values.
 //true; let foo = false;
 type BooleanLiteral struct {
@@ -189,6 +359,10 @@ func (b *BooleanLiteral) String() string {
 	return b.TokenLiteral()
 }
 
+//IfExpression represents `if (x) { ... } else { ... }`.
+//In a chain like `if (a) { } else if (b) { } else { }` the Alternative
+//of each if is a block holding nothing but the next if, a block whose
+//token is that 'if'.
 type IfExpression struct {
 	Token       token.Token //if
 	Condition   Expression
@@ -208,7 +382,7 @@ func (ifEx *IfExpression) String() string {
 	out.WriteString(ifEx.Consequence.String())
 
 	if ifEx.Alternative != nil {
-		out.WriteString("else ")
+		out.WriteString(" else ")
 		out.WriteString(ifEx.Alternative.String())
 	}
 
@@ -118,4 +118,37 @@ func TestBooleanLiteralToString(t *testing.T){
 	}
 
 	is.Equal("false", program.String())
-}
\ No newline at end of file
+}
+func TestStringLiteralToString(t *testing.T) {
+	is := is2.New(t)
+	program := &Root{
+		Statements: []Statement{
+			&ExpressionStatement{
+				Token: token.Token{Type: token.STRING, Literal: "a \"quoted\"\tword\n"},
+				Expression: &StringLiteral{
+					Token: token.Token{Type: token.STRING, Literal: "a \"quoted\"\tword\n"},
+					Value: "a \"quoted\"\tword\n",
+				},
+			},
+		},
+	}
+
+	is.Equal(`"a \"quoted\"\tword\n"`, program.String())
+}
+
+func TestTemplateLiteralToString(t *testing.T) {
+	is := is2.New(t)
+	template := &TemplateLiteral{
+		Token: token.Token{Type: token.TEMPLATE_HEAD, Literal: "Hi \"${"},
+		Texts: []string{"Hi \"${", "!"},
+		Expressions: []Expression{
+			&IdentifierStatement{
+				Token: token.Token{Type: token.IDENTIFIER, Literal: "name"},
+				Value: "name",
+			},
+		},
+	}
+
+	is.Equal(`"Hi \"\${${name}!"`, template.String())
+	is.Equal(`"costs $5, not \${5}"`, (&StringLiteral{Value: "costs $5, not ${5}"}).String())
+}
@@ -0,0 +1,33 @@
+package ast
+
+import "interpreter_in_go/token"
+
+//BadStatement takes the place of a statement with a syntax error.
+//It goes from Token, where the statement starts, up to End, right
+//after the last token the parser skipped to recover from the error.
+type BadStatement struct {
+	Token token.Token
+	End   token.Position
+}
+
+func (bs *BadStatement) statementNode() {}
+func (bs *BadStatement) TokenLiteral() string {
+	return bs.Token.Literal
+}
+func (bs *BadStatement) String() string {
+	return "<bad statement>"
+}
+
+//BadExpression takes the place of an expression that could not be
+//parsed, like an ILLEGAL token or a number too big to fit its type.
+type BadExpression struct {
+	Token token.Token
+}
+
+func (be *BadExpression) expressionNode() {}
+func (be *BadExpression) TokenLiteral() string {
+	return be.Token.Literal
+}
+func (be *BadExpression) String() string {
+	return "<bad expression>"
+}
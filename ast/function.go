@@ -6,9 +6,10 @@ import (
 	"strings"
 )
 
+//FunctionLiteral represents fn(x, y = 10, ...rest) { ... }.
 type FunctionLiteral struct {
 	Token      token.Token
-	Parameters []*IdentifierStatement
+	Parameters []*Parameter
 	Body       *BlockStatement
 }
 
@@ -35,6 +36,29 @@ func (f *FunctionLiteral) String() string {
 	return out.String()
 }
 
+//Parameter is one parameter of a function literal. Default is nil for a
+//parameter that must be given. A variadic parameter, always the last one,
+//gets the arguments that are left as an array.
+type Parameter struct {
+	Token    token.Token //the name, or the '...' of a variadic parameter
+	Name     *IdentifierStatement
+	Default  Expression
+	Variadic bool
+}
+
+func (p *Parameter) TokenLiteral() string {
+	return p.Token.Literal
+}
+func (p *Parameter) String() string {
+	switch {
+	case p.Variadic:
+		return "..." + p.Name.String()
+	case p.Default != nil:
+		return p.Name.String() + " = " + p.Default.String()
+	}
+	return p.Name.String()
+}
+
 type BlockStatement struct {
 	Token      token.Token
 	Statements []Statement
@@ -53,3 +77,89 @@ func (b *BlockStatement) String() string {
 
 	return out.String()
 }
+
+//CallExpression represents a call like add(1, 2) where Function is
+//whatever comes before the '(', an identifier or any other expression
+//that gives back a function: fn(x) { x }(5), curry(1)(2).
+type CallExpression struct {
+	Token     token.Token //the '(' token
+	Function  Expression
+	Arguments []Expression
+}
+
+func (c *CallExpression) expressionNode() {}
+func (c *CallExpression) TokenLiteral() string {
+	return c.Token.Literal
+}
+func (c *CallExpression) String() string {
+	var out bytes.Buffer
+	args := []string{}
+
+	for _, a := range c.Arguments {
+		args = append(args, a.String())
+	}
+
+	out.WriteString(c.Function.String())
+	out.WriteString("(")
+	out.WriteString(strings.Join(args, ", "))
+	out.WriteString(")")
+
+	return out.String()
+}
+
+//SpreadExpression represents '...args' in the arguments of a call,
+//which passes each element of args as an argument of its own.
+type SpreadExpression struct {
+	Token token.Token //the '...' token
+	Value Expression
+}
+
+func (se *SpreadExpression) expressionNode() {}
+func (se *SpreadExpression) TokenLiteral() string {
+	return se.Token.Literal
+}
+func (se *SpreadExpression) String() string {
+	return "..." + se.Value.String()
+}
+
+//PipeExpression represents 'xs |> map(double)', a call where the left
+//side goes in as the first argument. It is kept as written for printing,
+//Desugar gives the call it stands for.
+type PipeExpression struct {
+	Token token.Token //the '|>' token
+	Left  Expression
+	Right Expression
+}
+
+func (pe *PipeExpression) expressionNode() {}
+func (pe *PipeExpression) TokenLiteral() string {
+	return pe.Token.Literal
+}
+func (pe *PipeExpression) String() string {
+	return "(" + pe.Left.String() + " |> " + pe.Right.String() + ")"
+}
+
+//Desugar returns the call a pipeline stands for, with the pipelines on
+//its left desugared as well:
+//
+//	xs |> filter(isEven) |> map(double)   is   map(filter(xs, isEven), double)
+//	x |> f                                 is   f(x)
+func (pe *PipeExpression) Desugar() *CallExpression {
+	left := pe.Left
+	if pipe, ok := left.(*PipeExpression); ok {
+		left = pipe.Desugar()
+	}
+
+	if call, ok := pe.Right.(*CallExpression); ok {
+		return &CallExpression{
+			Token:     call.Token,
+			Function:  call.Function,
+			Arguments: append([]Expression{left}, call.Arguments...),
+		}
+	}
+	return &CallExpression{
+		Token:     pe.Token,
+		Function:  pe.Right,
+		Arguments: []Expression{left},
+	}
+}
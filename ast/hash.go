@@ -0,0 +1,39 @@
+package ast
+
+import (
+	"bytes"
+	"interpreter_in_go/token"
+	"strings"
+)
+
+//HashLiteral represents a map like {"name": "Thorsten", "age": 28}.
+//Pairs keep the order they were written in.
+type HashLiteral struct {
+	Token token.Token //the '{' token
+	Pairs []HashPair
+}
+
+//HashPair is one key: value entry of a HashLiteral.
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
+func (h *HashLiteral) expressionNode() {}
+func (h *HashLiteral) TokenLiteral() string {
+	return h.Token.Literal
+}
+func (h *HashLiteral) String() string {
+	var out bytes.Buffer
+	pairs := []string{}
+
+	for _, pair := range h.Pairs {
+		pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
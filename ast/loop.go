@@ -0,0 +1,119 @@
+package ast
+
+import (
+	"bytes"
+	"interpreter_in_go/token"
+	"strings"
+)
+
+//WhileStatement represents a loop like `while (x < 10) { x += 1; }`.
+type WhileStatement struct {
+	Token     token.Token //while
+	Condition Expression
+	Body      *BlockStatement
+}
+
+func (ws *WhileStatement) statementNode() {}
+func (ws *WhileStatement) TokenLiteral() string {
+	return ws.Token.Literal
+}
+func (ws *WhileStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("while (")
+	out.WriteString(ws.Condition.String())
+	out.WriteString(") ")
+	out.WriteString(ws.Body.String())
+	return out.String()
+}
+
+//ForStatement represents a loop like `for (let i = 0; i < n; i++) { ... }`.
+//Any of Init, Condition and Post is nil when left out: `for (;;) { ... }`.
+type ForStatement struct {
+	Token     token.Token //for
+	Init      Statement
+	Condition Expression
+	Post      Expression
+	Body      *BlockStatement
+}
+
+func (fs *ForStatement) statementNode() {}
+func (fs *ForStatement) TokenLiteral() string {
+	return fs.Token.Literal
+}
+func (fs *ForStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("for (")
+	if fs.Init != nil {
+		//a let statement comes with its own ';'
+		out.WriteString(strings.TrimSuffix(fs.Init.String(), ";"))
+	}
+	out.WriteString(";")
+	if fs.Condition != nil {
+		out.WriteString(" ")
+		out.WriteString(fs.Condition.String())
+	}
+	out.WriteString(";")
+	if fs.Post != nil {
+		out.WriteString(" ")
+		out.WriteString(fs.Post.String())
+	}
+	out.WriteString(") ")
+	out.WriteString(fs.Body.String())
+	return out.String()
+}
+
+//ForInStatement represents a loop over the elements of an array,
+//`for (x in xs) { ... }`, or over the pairs of a hash,
+//`for (k, v in h) { ... }`. Key is nil in the first form.
+type ForInStatement struct {
+	Token    token.Token //for
+	Key      *IdentifierStatement
+	Value    *IdentifierStatement
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (fi *ForInStatement) statementNode() {}
+func (fi *ForInStatement) TokenLiteral() string {
+	return fi.Token.Literal
+}
+func (fi *ForInStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("for (")
+	if fi.Key != nil {
+		out.WriteString(fi.Key.String())
+		out.WriteString(", ")
+	}
+	out.WriteString(fi.Value.String())
+	out.WriteString(" in ")
+	out.WriteString(fi.Iterable.String())
+	out.WriteString(") ")
+	out.WriteString(fi.Body.String())
+	return out.String()
+}
+
+//BreakStatement leaves the innermost loop.
+type BreakStatement struct {
+	Token token.Token
+}
+
+func (bs *BreakStatement) statementNode() {}
+func (bs *BreakStatement) TokenLiteral() string {
+	return bs.Token.Literal
+}
+func (bs *BreakStatement) String() string {
+	return bs.TokenLiteral() + ";"
+}
+
+//ContinueStatement skips to the next iteration of the innermost loop.
+type ContinueStatement struct {
+	Token token.Token
+}
+
+func (cs *ContinueStatement) statementNode() {}
+func (cs *ContinueStatement) TokenLiteral() string {
+	return cs.Token.Literal
+}
+func (cs *ContinueStatement) String() string {
+	return cs.TokenLiteral() + ";"
+}
@@ -0,0 +1,121 @@
+package ast
+
+import (
+	"bytes"
+	"interpreter_in_go/token"
+	"strings"
+)
+
+//Pattern is what a let statement binds its value to: a plain name,
+//or an array or hash pattern that takes the value apart.
+//let [first, ...others] = xs;
+//let {name, age: years} = person;
+type Pattern interface {
+	Node
+	patternNode()
+}
+
+func (i *IdentifierStatement) patternNode() {}
+
+//ArrayPattern binds the elements of an array in order.
+//Its last element may be a RestPattern.
+type ArrayPattern struct {
+	Token    token.Token //the '[' token
+	Elements []Pattern
+}
+
+func (ap *ArrayPattern) patternNode() {}
+func (ap *ArrayPattern) TokenLiteral() string {
+	return ap.Token.Literal
+}
+func (ap *ArrayPattern) String() string {
+	var out bytes.Buffer
+	elements := []string{}
+
+	for _, e := range ap.Elements {
+		elements = append(elements, e.String())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+//HashPattern binds values of a hash by their keys.
+type HashPattern struct {
+	Token token.Token //the '{' token
+	Pairs []HashPatternPair
+}
+
+//HashPatternPair binds the value under Key to Value.
+//In the short form, {name}, Value is the same name as Key.
+type HashPatternPair struct {
+	Key   Expression //an identifier or a string
+	Value Pattern
+}
+
+func (hp *HashPattern) patternNode() {}
+func (hp *HashPattern) TokenLiteral() string {
+	return hp.Token.Literal
+}
+func (hp *HashPattern) String() string {
+	var out bytes.Buffer
+	pairs := []string{}
+
+	for _, pair := range hp.Pairs {
+		if pair.short() {
+			pairs = append(pairs, pair.Value.String())
+		} else {
+			pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+		}
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+//short tells whether the pair can be written as {name} or {name = 1}.
+func (pair HashPatternPair) short() bool {
+	value := pair.Value
+	if d, ok := value.(*DefaultPattern); ok {
+		value = d.Target
+	}
+	key, ok := pair.Key.(*IdentifierStatement)
+	name, isName := value.(*IdentifierStatement)
+	return ok && isName && key.Value == name.Value
+}
+
+//DefaultPattern gives a value to Target when there is none to bind,
+//like b in let [a, b = 2] = [1];
+type DefaultPattern struct {
+	Token   token.Token //the '=' token
+	Target  Pattern
+	Default Expression
+}
+
+func (dp *DefaultPattern) patternNode() {}
+func (dp *DefaultPattern) TokenLiteral() string {
+	return dp.Token.Literal
+}
+func (dp *DefaultPattern) String() string {
+	return dp.Target.String() + " = " + dp.Default.String()
+}
+
+//RestPattern binds the elements left by an ArrayPattern as an array.
+type RestPattern struct {
+	Token  token.Token //the '...' token
+	Target *IdentifierStatement
+}
+
+func (rp *RestPattern) patternNode() {}
+func (rp *RestPattern) TokenLiteral() string {
+	return rp.Token.Literal
+}
+func (rp *RestPattern) String() string {
+	return "..." + rp.Target.String()
+}
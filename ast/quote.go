@@ -0,0 +1,47 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//quote returns s as a double quoted Monkey string, escaping
+//whatever the lexer would not read back as the same value.
+func quote(s string) string {
+	return `"` + escape(s) + `"`
+}
+
+//escape returns s ready to be written between double quotes.
+func escape(s string) string {
+	var out strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&out, `\x%02x`, s[i])
+		case r == '"':
+			out.WriteString(`\"`)
+		case r == '\\':
+			out.WriteString(`\\`)
+		case r == '\n':
+			out.WriteString(`\n`)
+		case r == '\t':
+			out.WriteString(`\t`)
+		case r == '\r':
+			out.WriteString(`\r`)
+		case r == '$' && strings.HasPrefix(s[i+size:], "{"):
+			//it would start an interpolation
+			out.WriteString(`\$`)
+		case unicode.IsPrint(r):
+			out.WriteRune(r)
+		case r < utf8.RuneSelf:
+			fmt.Fprintf(&out, `\x%02x`, r)
+		default:
+			fmt.Fprintf(&out, `\u{%x}`, r)
+		}
+		i += size
+	}
+	return out.String()
+}
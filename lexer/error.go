@@ -0,0 +1,58 @@
+package lexer
+
+import (
+	"interpreter_in_go/token"
+)
+
+//ErrorKind tells what sort of problem the lexer found.
+type ErrorKind int
+
+const (
+	UnexpectedChar      ErrorKind = iota + 1 // a char that does not start any token
+	UnterminatedString                       // "abc
+	UnterminatedComment                      // /* abc
+	InvalidEscape                            // "\q"
+	InvalidNumber                            // 0x, 1__0
+	InvalidEncoding                          // bytes that are not valid UTF-8
+	ReadFailure                              // the io.Reader returned an error
+)
+
+var errorKindNames = map[ErrorKind]string{
+	UnexpectedChar:      "unexpected-char",
+	UnterminatedString:  "unterminated-string",
+	UnterminatedComment: "unterminated-comment",
+	InvalidEscape:       "invalid-escape",
+	InvalidNumber:       "invalid-number",
+	InvalidEncoding:     "invalid-encoding",
+	ReadFailure:         "read-failure",
+}
+
+func (k ErrorKind) String() string {
+	if name, ok := errorKindNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+//Error describes a problem found by the lexer while reading the input.
+//The lexer does not stop on errors: the offending text becomes an
+//ILLEGAL token (or, for a bad escape sequence, is left out of its
+//string) and lexing goes on right after it.
+type Error struct {
+	Kind ErrorKind
+	Pos  token.Position
+	Msg  string
+}
+
+func (e Error) Error() string {
+	return e.Pos.String() + ": " + e.Msg
+}
+
+//Errors returns every problem found so far, in the order they were found.
+func (l *Lexer) Errors() []Error {
+	return l.errors
+}
+
+func (l *Lexer) error(kind ErrorKind, pos token.Position, msg string) {
+	l.errors = append(l.errors, Error{Kind: kind, Pos: pos, Msg: msg})
+}
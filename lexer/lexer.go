@@ -1,19 +1,91 @@
 package lexer
 
 import (
+	"fmt"
 	"interpreter_in_go/token"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
+//eof is the char under examination once the whole input is read.
+const eof = -1
+
+//bom is ignored when found at the very beginning of the input.
+const bom = "\uFEFF"
+
+//Lexer reads UTF-8 encoded source code and splits it into tokens.
+//Offsets are counted in bytes while columns are counted in runes.
+//
+//When reading from an io.Reader, input only holds a window of the source
+//starting at the current token, base being the offset of its first byte.
+//Everything that outlives a call to next() is then kept as an offset
+//(see offset and slice) because the window moves as more input is read.
 type Lexer struct {
-	input   string
-	pos     int  // current position in input (points to current char)
-	readPos int  // current reading position in input (after current char)
-	char    byte // current char under examination
+	input    string
+	filename string
+	pos      int  // current position in input (points to current char)
+	readPos  int  // current reading position in input (after current char)
+	char     rune // current char under examination
+	line     int  // line of the current char
+	col      int  // column of the current char
+
+	rd      io.Reader // where more input comes from, nil once it is all read
+	buf     []byte    // scratch space to read from rd
+	bufSize int
+	base    int // offset of input[0] in the source
+	mark    int // offset of the first byte that is still needed
+
+	keepComments bool
+	keywords     token.Keywords    //nil for the default keywords
+	names        map[string]string //see intern
+	templates    []template        //strings with an open interpolation, innermost last
+
+	errors []Error
+}
+
+//Option allows to change the default behaviour of a Lexer.
+type Option func(*Lexer)
+
+//WithFilename sets the file name reported in the position of every token.
+func WithFilename(name string) Option {
+	return func(l *Lexer) {
+		l.filename = name
+	}
+}
+
+//WithComments makes the lexer return comments as COMMENT tokens
+//instead of skipping them. The parser skips them on its own.
+func WithComments() Option {
+	return func(l *Lexer) {
+		l.keepComments = true
+	}
+}
+
+//WithKeywords makes the lexer use its own keywords instead of the
+//default ones, e.g. to lex a dialect with localized keywords. The table
+//is copied so changing it afterwards has no effect on the lexer.
+func WithKeywords(kw token.Keywords) Option {
+	return func(l *Lexer) {
+		l.keywords = kw.Copy()
+	}
 }
 
 //NewLexer creates a new instance of Lexer
-func NewLexer(src string) *Lexer {
-	lx := &Lexer{input: src}
+func NewLexer(src string, opts ...Option) *Lexer {
+	return newLexer(&Lexer{input: src}, opts)
+}
+
+func newLexer(lx *Lexer, opts []Option) *Lexer {
+	lx.line = 1
+	for _, opt := range opts {
+		opt(lx)
+	}
+	lx.ensure(len(bom))
+	if strings.HasPrefix(lx.input, bom) {
+		lx.readPos = len(bom)
+	}
 	lx.next()
 	return lx
 }
@@ -21,130 +93,431 @@ func NewLexer(src string) *Lexer {
 //NextToken retrieves the next token present in the lexer.
 //Works like an iterator over a set of elements. Reads a token
 //and moves forward.
-func (l *Lexer) NextToken() *token.Token {
-	var tkn *token.Token
+//
+//Tokens are returned by value and their literals are slices of the
+//input, so reading a token does not allocate. The exceptions are
+//strings with escape sequences, which need a new string for their
+//value, and the first time each identifier shows up (see intern).
+func (l *Lexer) NextToken() token.Token {
+	var tkn token.Token
 
-	//skip whitespace
-	for l.char == ' ' || l.char == '\t' || l.char == '\n' || l.char == '\r' {
-		l.next()
+	//skip whitespace and, unless asked to keep them, comments
+	l.skipWhitespace()
+	for l.char == '/' && (l.peekChar() == '/' || l.peekChar() == '*') {
+		start := l.startToken()
+		if !l.readComment() {
+			l.error(UnterminatedComment, start, "unterminated block comment")
+			//a skipped comment is gone by now, all but its opening
+			lit := "/*"
+			if l.keepComments {
+				lit = l.slice(start.Offset, l.offset())
+			}
+			return token.Token{
+				Type:    token.ILLEGAL,
+				Literal: lit,
+				Start:   start,
+				End:     l.position(),
+			}
+		}
+		if l.keepComments {
+			return token.Token{
+				Type:    token.COMMENT,
+				Literal: l.slice(start.Offset, l.offset()),
+				Start:   start,
+				End:     l.position(),
+			}
+		}
+		l.skipWhitespace()
 	}
 
+	start := l.startToken()
+
 	switch l.char {
 	case '=':
-		nxt := l.peekChar()
-		if nxt == '=' {
-			tkn = &token.Token{
-				Literal : "==",
-				Type: token.EQUAL,
-			}
-			//we just read another token, we have to move the pointer forward as well.
-			l.next()
+		if l.peekChar() == '=' {
+			tkn = l.twoCharToken(token.EQUAL)
 		} else {
-			tkn = token.NewToken(token.ASSIGN, l.char)
-	    }
+			tkn = l.charToken(token.ASSIGN)
+		}
 	case ';':
-		tkn = token.NewToken(token.SEMICOLON, l.char)
+		tkn = l.charToken(token.SEMICOLON)
 	case '(':
-		tkn = token.NewToken(token.LPAREN, l.char)
+		tkn = l.charToken(token.LPAREN)
 	case ')':
-		tkn = token.NewToken(token.RPAREN, l.char)
+		tkn = l.charToken(token.RPAREN)
 	case ',':
-		tkn = token.NewToken(token.COMMA, l.char)
+		tkn = l.charToken(token.COMMA)
+	case '[':
+		tkn = l.charToken(token.LBRACKET)
+	case ']':
+		tkn = l.charToken(token.RBRACKET)
+	case ':':
+		tkn = l.charToken(token.COLON)
+	case '.':
+		if l.peekByte(0) == '.' && l.peekByte(1) == '.' {
+			l.next()
+			tkn = l.twoCharToken(token.ELLIPSIS)
+		} else {
+			tkn = l.charToken(token.DOT)
+		}
 	case '+':
-		tkn = token.NewToken(token.PLUS, l.char)
+		switch l.peekChar() {
+		case '+':
+			tkn = l.twoCharToken(token.INCREMENT)
+		case '=':
+			tkn = l.twoCharToken(token.PLUS_ASSIGN)
+		default:
+			tkn = l.charToken(token.PLUS)
+		}
 	case '{':
-		tkn = token.NewToken(token.LBRACE, l.char)
+		if n := len(l.templates); n > 0 {
+			l.templates[n-1].depth++
+		}
+		tkn = l.charToken(token.LBRACE)
 	case '}':
-		tkn = token.NewToken(token.RBRACE, l.char)
-	case '!':
-		nxt := l.peekChar()
-		if nxt == '=' {
-			tkn = &token.Token{
-				Literal : "!=",
-				Type: token.NOT_EQUAL,
-			}
+		n := len(l.templates)
+		if n > 0 && l.templates[n-1].depth == 0 {
+			//end of an interpolation, the string goes on
 			l.next()
+			return l.stringToken(start, true)
+		}
+		if n > 0 {
+			l.templates[n-1].depth--
+		}
+		tkn = l.charToken(token.RBRACE)
+	case '!':
+		if l.peekChar() == '=' {
+			tkn = l.twoCharToken(token.NOT_EQUAL)
 		} else {
-			tkn = token.NewToken(token.BANG, l.char)
+			tkn = l.charToken(token.BANG)
 		}
 	case '*':
-		tkn = token.NewToken(token.ASTERISK, l.char)
+		if l.peekChar() == '=' {
+			tkn = l.twoCharToken(token.ASTERISK_ASSIGN)
+		} else {
+			tkn = l.charToken(token.ASTERISK)
+		}
 	case '/':
-		tkn = token.NewToken(token.SLASH, l.char)
+		//comments were already skipped, so this is a division
+		if l.peekChar() == '=' {
+			tkn = l.twoCharToken(token.SLASH_ASSIGN)
+		} else {
+			tkn = l.charToken(token.SLASH)
+		}
+	case '%':
+		tkn = l.charToken(token.PERCENT)
 	case '-':
-		tkn = token.NewToken(token.MINUS, l.char)
+		switch l.peekChar() {
+		case '-':
+			tkn = l.twoCharToken(token.DECREMENT)
+		case '=':
+			tkn = l.twoCharToken(token.MINUS_ASSIGN)
+		default:
+			tkn = l.charToken(token.MINUS)
+		}
 	case '<':
-		tkn = token.NewToken(token.LT, l.char)
+		if l.peekChar() == '=' {
+			tkn = l.twoCharToken(token.LT_EQUAL)
+		} else {
+			tkn = l.charToken(token.LT)
+		}
 	case '>':
-		tkn = token.NewToken(token.GT, l.char)
-	case 0:
-		tkn = &token.Token{
-			Literal : "",
-			Type : token.EOF,
+		if l.peekChar() == '=' {
+			tkn = l.twoCharToken(token.GT_EQUAL)
+		} else {
+			tkn = l.charToken(token.GT)
+		}
+	case '&':
+		if l.peekChar() == '&' {
+			tkn = l.twoCharToken(token.AND)
+		} else {
+			tkn = l.illegalChar(start)
+		}
+	case '|':
+		switch l.peekChar() {
+		case '|':
+			tkn = l.twoCharToken(token.OR)
+		case '>':
+			tkn = l.twoCharToken(token.PIPE)
+		default:
+			tkn = l.illegalChar(start)
+		}
+	case '?':
+		if l.peekChar() == '?' {
+			tkn = l.twoCharToken(token.COALESCE)
+		} else {
+			tkn = l.charToken(token.QUESTION)
+		}
+	case '"':
+		l.next()
+		return l.stringToken(start, false)
+	case '`':
+		return l.rawStringToken(start)
+	case eof:
+		for _, t := range l.templates {
+			l.error(UnterminatedString, t.start, "unterminated string interpolation")
+		}
+		l.templates = nil
+		tkn = token.Token{
+			Literal: "",
+			Type:    token.EOF,
 		}
 	default: //keywords and identifiers
 		if isLetter(l.char) {
-			tkn = &token.Token{}
-			tkn.Literal = l.readIdentifier()
-			tkn.Type = token.GetIdentifier(tkn.Literal)
+			tkn = token.Token{}
+			tkn.Literal = l.intern(l.readIdentifier())
+			tkn.Type = l.lookup(tkn.Literal)
+			tkn.Start, tkn.End = start, l.position()
 			return tkn
 		} else if isDigit(l.char) {
-			tkn = &token.Token{}
-			tkn.Literal = l.readNumber()
-			tkn.Type = token.INT
+			tkn = token.Token{}
+			tkn.Type = l.readNumber()
+			tkn.Literal = l.slice(start.Offset, l.offset())
+			tkn.Start, tkn.End = start, l.position()
 			return tkn
 		} else {
-			tkn = token.NewToken(token.ILLEGAL, l.char)
+			tkn = l.illegalChar(start)
 		}
 	}
 
 	//move forward the read position pointer
 	l.next()
+	tkn.Start, tkn.End = start, l.position()
 	return tkn
 }
 
+//illegalChar reports the current char as unexpected and
+//creates an ILLEGAL token for it.
+func (l *Lexer) illegalChar(start token.Position) token.Token {
+	//keep the original bytes, the char may not be valid UTF-8
+	lit := l.input[l.pos:l.readPos]
+	//bad encodings were reported as soon as they were read
+	if l.char != utf8.RuneError || len(lit) > 1 {
+		l.error(UnexpectedChar, start, fmt.Sprintf("unexpected character %q", l.char))
+	}
+	return token.Token{
+		Type:    token.ILLEGAL,
+		Literal: lit,
+	}
+}
+
+//charToken creates an operator or delimiter token. Those are named
+//after themselves, so the type is also the literal.
+func (l *Lexer) charToken(t token.Type) token.Token {
+	return token.Token{
+		Type:    t,
+		Literal: string(t),
+	}
+}
+
+//lookup tells keywords and identifiers apart.
+func (l *Lexer) lookup(ident string) token.Type {
+	if l.keywords == nil {
+		return token.GetIdentifier(ident)
+	}
+	return l.keywords.Lookup(ident)
+}
+
+//intern returns the one copy of an identifier or keyword the lexer keeps,
+//so tokens with the same name share their literal and do not hold on to
+//the input (which matters when it comes from an io.Reader).
+func (l *Lexer) intern(name string) string {
+	if in, ok := l.names[name]; ok {
+		return in
+	}
+	if l.names == nil {
+		l.names = make(map[string]string)
+	}
+	in := string([]byte(name))
+	l.names[in] = in
+	return in
+}
+
+//twoCharToken creates a token made of the current char and the next one.
+//It leaves the lexer on the second char.
+func (l *Lexer) twoCharToken(t token.Type) token.Token {
+	//we are reading another char, we have to move the pointer forward as well.
+	l.next()
+	return l.charToken(t)
+}
+
+//next moves to the following char, decoding it from UTF-8.
 func (l *Lexer) next() {
-	if l.readPos >= len(l.input) {
-		l.char = 0
-	} else {
-		l.char = l.input[l.readPos]
+	//update line and column of the char we are about to read
+	switch {
+	case l.col == 0:
+		//nothing was read yet
+		l.col = 1
+	case l.atEOF():
+		return
+	case l.char == '\n':
+		l.line++
+		l.col = 1
+	case l.char == '\r' && l.peekChar() != '\n':
+		//a lonely '\r' also breaks the line. The '\r' of a
+		//"\r\n" pair is left for the '\n' to handle.
+		l.line++
+		l.col = 1
+	default:
+		l.col++
 	}
+
+	l.ensure(utf8.UTFMax)
 	l.pos = l.readPos
-	l.readPos += 1
+	if l.pos >= len(l.input) {
+		l.char = eof
+		return
+	}
+	r, size := rune(l.input[l.pos]), 1
+	if r >= utf8.RuneSelf {
+		r, size = utf8.DecodeRuneInString(l.input[l.pos:])
+		if r == utf8.RuneError && size == 1 {
+			l.error(InvalidEncoding, l.position(), fmt.Sprintf("invalid UTF-8 encoding: byte %#x", l.input[l.pos]))
+		}
+	}
+	l.char = r
+	l.readPos += size
+}
+
+func (l *Lexer) skipWhitespace() {
+	for l.char == ' ' || l.char == '\t' || l.char == '\n' || l.char == '\r' {
+		l.next()
+		//no need to hold on to whitespace
+		l.mark = l.offset()
+	}
+}
+
+//readComment reads a `// line comment` up to the end of the line or a
+//`/* block comment */`. Block comments can be nested, so every `/*` needs
+//its own `*/`. Reports false when the input ends before the block is closed.
+//Unless comments are kept, the comment is let go as it is read, so a
+//long one does not have to fit in memory.
+func (l *Lexer) readComment() bool {
+	if l.peekChar() == '/' {
+		for l.char != '\n' && l.char != '\r' && !l.atEOF() {
+			l.next()
+			if !l.keepComments {
+				l.mark = l.offset()
+			}
+		}
+		return true
+	}
+
+	depth := 0
+	for !l.atEOF() {
+		if !l.keepComments {
+			l.mark = l.offset()
+		}
+		switch {
+		case l.char == '/' && l.peekChar() == '*':
+			depth++
+			l.next()
+		case l.char == '*' && l.peekChar() == '/':
+			depth--
+			l.next()
+		}
+		l.next()
+		if depth == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+//atEOF reports whether the whole input has been read.
+func (l *Lexer) atEOF() bool {
+	return l.pos >= len(l.input)
+}
+
+//position of the current char
+func (l *Lexer) position() token.Position {
+	return token.Position{
+		Filename: l.filename,
+		Offset:   l.offset(),
+		Line:     l.line,
+		Column:   l.col,
+	}
+}
+
+//offset of the current char in the source
+func (l *Lexer) offset() int {
+	return l.base + l.pos
+}
+
+//slice returns the source between two offsets. The input before the
+//start of the current token may be gone, so from can not be any earlier.
+func (l *Lexer) slice(from, to int) string {
+	return l.input[from-l.base : to-l.base]
+}
+
+//startToken marks the current char as the beginning of a token
+//and returns its position.
+func (l *Lexer) startToken() token.Position {
+	l.mark = l.offset()
+	return l.position()
 }
 
 //read a certain value accordingly with the given predicate
-func (l *Lexer) read(pred func(byte) bool) string {
-	pos := l.pos
+func (l *Lexer) read(pred func(rune) bool) string {
+	from := l.offset()
 	for pred(l.char) {
 		l.next()
 	}
-	return l.input[pos:l.pos]
+	return l.slice(from, l.offset())
 }
 
-//read entire word
+//read entire word. An identifier starts with a letter or '_' and
+//goes on with letters, digits and '_'. Letters and digits are the
+//ones defined by Unicode, so `café` and `日本` are valid names.
 func (l *Lexer) readIdentifier() string {
-	return l.read(isLetter)
+	return l.read(isIdentifierChar)
 }
 
-//read entire digit. This ignores anything different than integers.
-func (l *Lexer) readNumber() string {
-	return l.read(isDigit)
+func isLetter(ch rune) bool {
+	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_' ||
+		ch >= utf8.RuneSelf && unicode.IsLetter(ch)
 }
 
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+func isIdentifierChar(ch rune) bool {
+	return isLetter(ch) || isDigit(ch) || ch >= utf8.RuneSelf && unicode.IsDigit(ch)
 }
 
-func isDigit(ch byte) bool {
+//isDigit only accepts ASCII digits, numbers are not written in other scripts.
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
 
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
+}
+
+func hexValue(ch rune) byte {
+	switch {
+	case isDigit(ch):
+		return byte(ch - '0')
+	case 'a' <= ch && ch <= 'f':
+		return byte(ch - 'a' + 10)
+	default:
+		return byte(ch - 'A' + 10)
+	}
+}
+
 //peekChar helps to check the next char without moving the read pointer.
-func (l *Lexer) peekChar() byte {
+func (l *Lexer) peekChar() rune {
+	l.ensure(utf8.UTFMax)
 	if l.readPos >= len(l.input) {
+		return eof
+	}
+	r, _ := utf8.DecodeRuneInString(l.input[l.readPos:])
+	return r
+}
+
+//peekByte looks n bytes further than the current char. It is
+//only meant to look ahead for ASCII chars.
+func (l *Lexer) peekByte(n int) byte {
+	l.ensure(n + 1)
+	if l.readPos+n >= len(l.input) {
 		return 0
-	} else {
-		return l.input[l.readPos]
 	}
-}
\ No newline at end of file
+	return l.input[l.readPos+n]
+}
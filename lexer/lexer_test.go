@@ -1,9 +1,16 @@
 package lexer
 
 import (
+	"errors"
 	is2 "github.com/matryer/is"
 	"interpreter_in_go/token"
+	"io"
+	"runtime"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
+	"unicode/utf8"
 )
 
 func TestNextToken(t *testing.T) {
@@ -103,7 +110,7 @@ func TestNextTokenWithCode2(t *testing.T) {
 		  x + y;
 		};
 		let result = add(five, ten);
-		!-/*5;
+		!-/ *5;
 		5 < 10 > 5;
 		`
 	tests := []struct {
@@ -173,7 +180,7 @@ func TestNextTokenWithCode2(t *testing.T) {
 
 func TestNextTokenWithCode3(t *testing.T) {
 	is := is2.New(t)
-	input := `!-/*5;
+	input := `!-/ *5;
 		5 < 10 > 5;
 		if (5 < 10) {
 		  return true;
@@ -254,4 +261,819 @@ func TestNextTokenWithComparisons(t *testing.T) {
 		is.True(tkn.Type == tt.expectedType)
 		is.Equal(tkn.Literal, tt.expectedLiteral)
 	}
-}
\ No newline at end of file
+}
+func TestTokenPositions(t *testing.T) {
+	is := is2.New(t)
+	input := "let x = 10;\r\n\tx != y\rfoo"
+
+	tests := []struct {
+		expectedType token.Type
+		start        token.Position
+		end          token.Position
+	}{
+		{token.LET, token.Position{Offset: 0, Line: 1, Column: 1}, token.Position{Offset: 3, Line: 1, Column: 4}},
+		{token.IDENTIFIER, token.Position{Offset: 4, Line: 1, Column: 5}, token.Position{Offset: 5, Line: 1, Column: 6}},
+		{token.ASSIGN, token.Position{Offset: 6, Line: 1, Column: 7}, token.Position{Offset: 7, Line: 1, Column: 8}},
+		{token.INT, token.Position{Offset: 8, Line: 1, Column: 9}, token.Position{Offset: 10, Line: 1, Column: 11}},
+		{token.SEMICOLON, token.Position{Offset: 10, Line: 1, Column: 11}, token.Position{Offset: 11, Line: 1, Column: 12}},
+		{token.IDENTIFIER, token.Position{Offset: 14, Line: 2, Column: 2}, token.Position{Offset: 15, Line: 2, Column: 3}},
+		{token.NOT_EQUAL, token.Position{Offset: 16, Line: 2, Column: 4}, token.Position{Offset: 18, Line: 2, Column: 6}},
+		{token.IDENTIFIER, token.Position{Offset: 19, Line: 2, Column: 7}, token.Position{Offset: 20, Line: 2, Column: 8}},
+		{token.IDENTIFIER, token.Position{Offset: 21, Line: 3, Column: 1}, token.Position{Offset: 24, Line: 3, Column: 4}},
+		{token.EOF, token.Position{Offset: 24, Line: 3, Column: 4}, token.Position{Offset: 24, Line: 3, Column: 4}},
+	}
+
+	lx := NewLexer(input)
+
+	for _, tt := range tests {
+		tkn := lx.NextToken()
+
+		is.Equal(tkn.Type, tt.expectedType)
+		is.Equal(tkn.Start, tt.start)
+		is.Equal(tkn.End, tt.end)
+	}
+}
+
+func TestTokenPositionsWithFilename(t *testing.T) {
+	is := is2.New(t)
+
+	lx := NewLexer("\n  add", WithFilename("math.mk"))
+	tkn := lx.NextToken()
+
+	is.Equal(tkn.Start.String(), "math.mk:2:3")
+	is.Equal(tkn.End.String(), "math.mk:2:6")
+}
+
+func TestNextTokenWithStrings(t *testing.T) {
+	is := is2.New(t)
+	input := `let name = "Monkey";
+		"tab\tnew\nline" "say \"hi\"" "back\\slash"
+		"\x41\u{e9}\u{1F600}" ""`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.LET, "let"},
+		{token.IDENTIFIER, "name"},
+		{token.ASSIGN, "="},
+		{token.STRING, "Monkey"},
+		{token.SEMICOLON, ";"},
+		{token.STRING, "tab\tnew\nline"},
+		{token.STRING, `say "hi"`},
+		{token.STRING, `back\slash`},
+		{token.STRING, "Aé😀"},
+		{token.STRING, ""},
+		{token.EOF, ""},
+	}
+
+	lx := NewLexer(input)
+
+	for _, tt := range tests {
+		tkn := lx.NextToken()
+
+		is.Equal(tkn.Type, tt.expectedType)
+		is.Equal(tkn.Literal, tt.expectedLiteral)
+	}
+	is.Equal(len(lx.Errors()), 0)
+}
+
+func TestStringErrors(t *testing.T) {
+	is := is2.New(t)
+	tests := []struct {
+		input           string
+		expectedType    token.Type
+		expectedLiteral string
+		expectedError   string
+	}{
+		{`"abc`, token.ILLEGAL, `"abc`, "1:1: unterminated string"},
+		{"\"abc\nx\"", token.ILLEGAL, `"abc`, "1:1: unterminated string"},
+		{`"a\qb"`, token.STRING, "ab", `1:3: invalid escape sequence: \q`},
+		{`"\x4"`, token.STRING, "4", `1:2: invalid escape sequence: \x must be followed by two hex digits`},
+		{`"\u{110000}"`, token.STRING, "", "1:2: invalid escape sequence: U+110000 is not a valid code point"},
+		{`"\u{}"`, token.STRING, "", "1:2: invalid escape sequence: \\u{...} needs between one and six hex digits"},
+		{`"\u{1234567}"`, token.STRING, "", "1:2: invalid escape sequence: \\u{...} needs between one and six hex digits"},
+	}
+
+	for _, tt := range tests {
+		lx := NewLexer(tt.input)
+		tkn := lx.NextToken()
+
+		is.Equal(tkn.Type, tt.expectedType)
+		is.Equal(tkn.Literal, tt.expectedLiteral)
+		is.Equal(len(lx.Errors()), 1)
+		is.Equal(lx.Errors()[0].Error(), tt.expectedError)
+	}
+}
+
+func TestNextTokenSkipsComments(t *testing.T) {
+	is := is2.New(t)
+	input := `// the answer
+		let x = /* inline */ 42; // trailing
+		/* outer /* nested */ still a comment */
+		x / 2`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.LET, "let"},
+		{token.IDENTIFIER, "x"},
+		{token.ASSIGN, "="},
+		{token.INT, "42"},
+		{token.SEMICOLON, ";"},
+		{token.IDENTIFIER, "x"},
+		{token.SLASH, "/"},
+		{token.INT, "2"},
+		{token.EOF, ""},
+	}
+
+	lx := NewLexer(input)
+
+	for _, tt := range tests {
+		tkn := lx.NextToken()
+
+		is.Equal(tkn.Type, tt.expectedType)
+		is.Equal(tkn.Literal, tt.expectedLiteral)
+	}
+	is.Equal(len(lx.Errors()), 0)
+}
+
+func TestNextTokenWithComments(t *testing.T) {
+	is := is2.New(t)
+	input := "// doc\nlet /* a /* b */ */ x;"
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.COMMENT, "// doc"},
+		{token.LET, "let"},
+		{token.COMMENT, "/* a /* b */ */"},
+		{token.IDENTIFIER, "x"},
+		{token.SEMICOLON, ";"},
+		{token.EOF, ""},
+	}
+
+	lx := NewLexer(input, WithComments())
+
+	for _, tt := range tests {
+		tkn := lx.NextToken()
+
+		is.Equal(tkn.Type, tt.expectedType)
+		is.Equal(tkn.Literal, tt.expectedLiteral)
+	}
+}
+
+func TestUnterminatedBlockComment(t *testing.T) {
+	is := is2.New(t)
+	lx := NewLexer("x /* one /* two */\n")
+
+	is.Equal(lx.NextToken().Type, token.Type(token.IDENTIFIER))
+
+	tkn := lx.NextToken()
+	is.Equal(tkn.Type, token.Type(token.ILLEGAL))
+	is.Equal(tkn.Literal, "/*")
+	is.Equal(tkn.End.Offset, len("x /* one /* two */\n"))
+	is.Equal(len(lx.Errors()), 1)
+	is.Equal(lx.Errors()[0].Error(), "1:3: unterminated block comment")
+	is.Equal(lx.NextToken().Type, token.Type(token.EOF))
+
+	//a comment that is kept comes whole
+	lx = NewLexer("/* one /* two */\n", WithComments())
+	is.Equal(lx.NextToken().Literal, "/* one /* two */\n")
+}
+
+func TestNextTokenWithUnicode(t *testing.T) {
+	is := is2.New(t)
+	input := "let café = \"crème brûlée\"; x٣ + été2 日本"
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+		column          int
+	}{
+		{token.LET, "let", 1},
+		{token.IDENTIFIER, "café", 5},
+		{token.ASSIGN, "=", 10},
+		{token.STRING, "crème brûlée", 12},
+		{token.SEMICOLON, ";", 26},
+		{token.IDENTIFIER, "x٣", 28},
+		{token.PLUS, "+", 31},
+		{token.IDENTIFIER, "été2", 33},
+		{token.IDENTIFIER, "日本", 38},
+		{token.EOF, "", 40},
+	}
+
+	lx := NewLexer(input)
+
+	for _, tt := range tests {
+		tkn := lx.NextToken()
+
+		is.Equal(tkn.Type, tt.expectedType)
+		is.Equal(tkn.Literal, tt.expectedLiteral)
+		is.Equal(tkn.Start.Column, tt.column)
+	}
+	is.Equal(len(lx.Errors()), 0)
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	is := is2.New(t)
+	lx := NewLexer("a \xff b\x00")
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.IDENTIFIER, "a"},
+		{token.ILLEGAL, "\xff"},
+		{token.IDENTIFIER, "b"},
+		{token.ILLEGAL, "\x00"},
+		{token.EOF, ""},
+	}
+
+	for _, tt := range tests {
+		tkn := lx.NextToken()
+
+		is.Equal(tkn.Type, tt.expectedType)
+		is.Equal(tkn.Literal, tt.expectedLiteral)
+	}
+	is.Equal(len(lx.Errors()), 2)
+	is.Equal(lx.Errors()[0].Error(), "1:3: invalid UTF-8 encoding: byte 0xff")
+	is.Equal(lx.Errors()[0].Kind, InvalidEncoding)
+	is.Equal(lx.Errors()[0].Pos.Offset, 2)
+	is.Equal(lx.Errors()[1].Error(), `1:6: unexpected character '\x00'`)
+	is.Equal(lx.Errors()[1].Kind, UnexpectedChar)
+}
+
+func TestByteOrderMarkIsIgnored(t *testing.T) {
+	is := is2.New(t)
+	lx := NewLexer("\uFEFFlet")
+
+	tkn := lx.NextToken()
+	is.Equal(tkn.Type, token.Type(token.LET))
+	is.Equal(tkn.Start, token.Position{Offset: 3, Line: 1, Column: 1})
+}
+
+func TestNextTokenWithOperators(t *testing.T) {
+	is := is2.New(t)
+	input := `i++; --j; a <= b && c >= d || !e; x % 2;
+		x += 1; x -= 1; x *= 2; x /= 2; a ? b ?? c : d; a |> f; f(...xs); a & b | c`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.IDENTIFIER, "i"},
+		{token.INCREMENT, "++"},
+		{token.SEMICOLON, ";"},
+		{token.DECREMENT, "--"},
+		{token.IDENTIFIER, "j"},
+		{token.SEMICOLON, ";"},
+		{token.IDENTIFIER, "a"},
+		{token.LT_EQUAL, "<="},
+		{token.IDENTIFIER, "b"},
+		{token.AND, "&&"},
+		{token.IDENTIFIER, "c"},
+		{token.GT_EQUAL, ">="},
+		{token.IDENTIFIER, "d"},
+		{token.OR, "||"},
+		{token.BANG, "!"},
+		{token.IDENTIFIER, "e"},
+		{token.SEMICOLON, ";"},
+		{token.IDENTIFIER, "x"},
+		{token.PERCENT, "%"},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.IDENTIFIER, "x"},
+		{token.PLUS_ASSIGN, "+="},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+		{token.IDENTIFIER, "x"},
+		{token.MINUS_ASSIGN, "-="},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+		{token.IDENTIFIER, "x"},
+		{token.ASTERISK_ASSIGN, "*="},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.IDENTIFIER, "x"},
+		{token.SLASH_ASSIGN, "/="},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.IDENTIFIER, "a"},
+		{token.QUESTION, "?"},
+		{token.IDENTIFIER, "b"},
+		{token.COALESCE, "??"},
+		{token.IDENTIFIER, "c"},
+		{token.COLON, ":"},
+		{token.IDENTIFIER, "d"},
+		{token.SEMICOLON, ";"},
+		{token.IDENTIFIER, "a"},
+		{token.PIPE, "|>"},
+		{token.IDENTIFIER, "f"},
+		{token.SEMICOLON, ";"},
+		{token.IDENTIFIER, "f"},
+		{token.LPAREN, "("},
+		{token.ELLIPSIS, "..."},
+		{token.IDENTIFIER, "xs"},
+		{token.RPAREN, ")"},
+		{token.SEMICOLON, ";"},
+		{token.IDENTIFIER, "a"},
+		{token.ILLEGAL, "&"},
+		{token.IDENTIFIER, "b"},
+		{token.ILLEGAL, "|"},
+		{token.IDENTIFIER, "c"},
+		{token.EOF, ""},
+	}
+
+	lx := NewLexer(input)
+
+	for _, tt := range tests {
+		tkn := lx.NextToken()
+
+		is.Equal(tkn.Type, tt.expectedType)
+		is.Equal(tkn.Literal, tt.expectedLiteral)
+	}
+}
+
+func TestNextTokenWithBrackets(t *testing.T) {
+	is := is2.New(t)
+	input := `[1, xs[0]][a:b]`
+
+	tests := []token.Token{
+		{Type: token.LBRACKET, Literal: "["},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.IDENTIFIER, Literal: "xs"},
+		{Type: token.LBRACKET, Literal: "["},
+		{Type: token.INT, Literal: "0"},
+		{Type: token.RBRACKET, Literal: "]"},
+		{Type: token.RBRACKET, Literal: "]"},
+		{Type: token.LBRACKET, Literal: "["},
+		{Type: token.IDENTIFIER, Literal: "a"},
+		{Type: token.COLON, Literal: ":"},
+		{Type: token.IDENTIFIER, Literal: "b"},
+		{Type: token.RBRACKET, Literal: "]"},
+		{Type: token.EOF, Literal: ""},
+	}
+
+	lx := NewLexer(input)
+	for _, tt := range tests {
+		tkn := lx.NextToken()
+		is.Equal(tkn.Type, tt.Type)
+		is.Equal(tkn.Literal, tt.Literal)
+	}
+}
+
+func TestNextTokenWithNumbers(t *testing.T) {
+	is := is2.New(t)
+	input := `0 42 0xFF 0X_1f 0o755 0b1010 1_000_000 3.14 1e-9 2.5E+3 0.5 1_0.2_5 7.`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.INT, "0"},
+		{token.INT, "42"},
+		{token.INT, "0xFF"},
+		{token.INT, "0X_1f"},
+		{token.INT, "0o755"},
+		{token.INT, "0b1010"},
+		{token.INT, "1_000_000"},
+		{token.FLOAT, "3.14"},
+		{token.FLOAT, "1e-9"},
+		{token.FLOAT, "2.5E+3"},
+		{token.FLOAT, "0.5"},
+		{token.FLOAT, "1_0.2_5"},
+		{token.INT, "7"},
+		{token.DOT, "."},
+		{token.EOF, ""},
+	}
+
+	lx := NewLexer(input)
+
+	for _, tt := range tests {
+		tkn := lx.NextToken()
+
+		is.Equal(tkn.Type, tt.expectedType)
+		is.Equal(tkn.Literal, tt.expectedLiteral)
+	}
+	//the dot after 7 does not belong to the number
+	is.Equal(len(lx.Errors()), 0)
+}
+
+func TestMalformedNumbers(t *testing.T) {
+	is := is2.New(t)
+	tests := []struct {
+		input           string
+		expectedLiteral string
+		expectedError   string
+	}{
+		{"0x", "0x", "1:1: invalid number: hexadecimal literal has no digits"},
+		{"0b;", "0b", "1:1: invalid number: binary literal has no digits"},
+		{"1__0", "1__0", "1:2: invalid number: '_' must separate successive digits"},
+		{"10_", "10_", "1:3: invalid number: '_' must separate successive digits"},
+		{"0b102", "0b102", "1:5: invalid number: invalid digit '2' in binary literal"},
+		{"0o78", "0o78", "1:4: invalid number: invalid digit '8' in octal literal"},
+		{"1e+", "1e+", "1:4: invalid number: exponent has no digits"},
+		{"0755", "0755", "1:1: invalid number: leading zeros are not allowed, use 0o for octal numbers"},
+	}
+
+	for _, tt := range tests {
+		lx := NewLexer(tt.input)
+		tkn := lx.NextToken()
+
+		is.Equal(tkn.Type, token.Type(token.ILLEGAL))
+		is.Equal(tkn.Literal, tt.expectedLiteral)
+		is.Equal(len(lx.Errors()), 1)
+		is.Equal(lx.Errors()[0].Error(), tt.expectedError)
+	}
+}
+
+//lexAll returns every token up to EOF, included.
+func lexAll(lx *Lexer) []token.Token {
+	tkns := []token.Token{}
+	for {
+		tkn := lx.NextToken()
+		tkns = append(tkns, tkn)
+		if tkn.Type == token.EOF {
+			return tkns
+		}
+	}
+}
+
+func TestReaderLexerMatchesStringLexer(t *testing.T) {
+	is := is2.New(t)
+	input := "\uFEFFlet café = fn(x, y) {\r\n\tx += y * 0x_FF; // sum\n};\n" +
+		"/* a /* nested */ comment */ \"crème \\u{1F600}\\n\" 3.14e-2 a <= b && c != d\n" +
+		"```\n  a `b`\n``` \"日本語\" 1__0 \xff \"open"
+
+	strLexer := NewLexer(input, WithFilename("x.mk"))
+	expected := lexAll(strLexer)
+	is.Equal(len(strLexer.Errors()), 3)
+
+	readers := map[string]func() io.Reader{
+		"plain":    func() io.Reader { return strings.NewReader(input) },
+		"one byte": func() io.Reader { return iotest.OneByteReader(strings.NewReader(input)) },
+		"half":     func() io.Reader { return iotest.HalfReader(strings.NewReader(input)) },
+		"data err": func() io.Reader { return iotest.DataErrReader(strings.NewReader(input)) },
+	}
+
+	for _, size := range []int{1, 2, 3, 5, 16, 4096} {
+		for _, rd := range readers {
+			lx := NewReaderLexer(rd(), WithBufferSize(size), WithFilename("x.mk"))
+			is.Equal(lexAll(lx), expected)
+			is.Equal(lx.Errors(), strLexer.Errors())
+		}
+	}
+}
+
+func TestReaderLexerKeepsBufferBounded(t *testing.T) {
+	is := is2.New(t)
+	input := strings.Repeat("let counter = counter + 1; // again\n", 50000)
+	lx := NewReaderLexer(strings.NewReader(input), WithBufferSize(64))
+
+	count := 0
+	for tkn := lx.NextToken(); tkn.Type != token.EOF; tkn = lx.NextToken() {
+		is.True(len(lx.input) <= 64+len("counter")+utf8.UTFMax)
+		count++
+	}
+	is.Equal(count, 50000*7)
+	is.Equal(lx.position().Line, 50001)
+
+	//a long comment is not kept while it is skipped
+	for _, comment := range []string{
+		"// " + strings.Repeat("again ", 200000),
+		"/* " + strings.Repeat("again ", 200000) + "*/",
+	} {
+		lx = NewReaderLexer(strings.NewReader("a "+comment+"\nb"), WithBufferSize(64))
+		for tkn := lx.NextToken(); tkn.Type != token.EOF; tkn = lx.NextToken() {
+			is.True(tkn.Type == token.IDENTIFIER)
+			is.True(len(lx.input) <= 64+utf8.UTFMax)
+		}
+	}
+}
+
+func TestReaderLexerReportsReadErrors(t *testing.T) {
+	is := is2.New(t)
+	rd := io.MultiReader(strings.NewReader("let x"), iotest.ErrReader(errors.New("disk on fire")))
+	lx := NewReaderLexer(rd)
+
+	is.Equal(lexAll(lx)[1].Literal, "x")
+	is.Equal(len(lx.Errors()), 1)
+	is.Equal(lx.Errors()[0].Msg, "could not read the input: disk on fire")
+}
+
+func TestErrorKindsAndRecovery(t *testing.T) {
+	is := is2.New(t)
+	input := `let a = @b;
+		"bad \q" 0x "open
+		c # 1 /* never closed`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.LET, "let"},
+		{token.IDENTIFIER, "a"},
+		{token.ASSIGN, "="},
+		{token.ILLEGAL, "@"},
+		{token.IDENTIFIER, "b"},
+		{token.SEMICOLON, ";"},
+		{token.STRING, "bad "},
+		{token.ILLEGAL, "0x"},
+		{token.ILLEGAL, `"open`},
+		{token.IDENTIFIER, "c"},
+		{token.ILLEGAL, "#"},
+		{token.INT, "1"},
+		{token.ILLEGAL, "/*"},
+		{token.EOF, ""},
+	}
+
+	lx := NewLexer(input)
+
+	for _, tt := range tests {
+		tkn := lx.NextToken()
+
+		is.Equal(tkn.Type, tt.expectedType)
+		is.Equal(tkn.Literal, tt.expectedLiteral)
+	}
+
+	expected := []struct {
+		kind ErrorKind
+		pos  string
+	}{
+		{UnexpectedChar, "1:9"},
+		{InvalidEscape, "2:8"},
+		{InvalidNumber, "2:12"},
+		{UnterminatedString, "2:15"},
+		{UnexpectedChar, "3:5"},
+		{UnterminatedComment, "3:9"},
+	}
+
+	is.Equal(len(lx.Errors()), len(expected))
+	for i, e := range expected {
+		is.Equal(lx.Errors()[i].Kind, e.kind)
+		is.Equal(lx.Errors()[i].Pos.String(), e.pos)
+	}
+	is.Equal(UnterminatedComment.String(), "unterminated-comment")
+}
+
+//benchmarkProgram is repeated to build large inputs for the benchmarks.
+const benchmarkProgram = `let add = fn(first, second) { return first + second * 10; };
+if (add(1, 2) >= limit && !done) { counter += 1; } // count it
+let name = "Monkey"; let rate = 0.25; let mask = 0xFF_FF;
+`
+
+func TestNextTokenDoesNotAllocate(t *testing.T) {
+	is := is2.New(t)
+	lx := NewLexer(strings.Repeat(benchmarkProgram, 200))
+	//meet every identifier once so they are interned
+	for i := 0; i < 100; i++ {
+		lx.NextToken()
+	}
+
+	allocs := testing.AllocsPerRun(1000, func() {
+		lx.NextToken()
+	})
+	is.Equal(allocs, float64(0))
+}
+
+func BenchmarkNextToken(b *testing.B) {
+	benchmarkLexer(b, func(src string) *Lexer {
+		return NewLexer(src)
+	})
+}
+
+func BenchmarkNextTokenFromReader(b *testing.B) {
+	benchmarkLexer(b, func(src string) *Lexer {
+		return NewReaderLexer(strings.NewReader(src))
+	})
+}
+
+//benchmarkLexer lexes about 300KB of code per iteration and reports
+//how many tokens per second it got through and the allocations per token.
+func benchmarkLexer(b *testing.B, newLexer func(string) *Lexer) {
+	src := strings.Repeat(benchmarkProgram, 2000)
+	b.SetBytes(int64(len(src)))
+	b.ReportAllocs()
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	tokens := 0
+	b.ResetTimer()
+	start := time.Now()
+
+	for i := 0; i < b.N; i++ {
+		lx := newLexer(src)
+		for tkn := lx.NextToken(); tkn.Type != token.EOF; tkn = lx.NextToken() {
+			tokens++
+		}
+	}
+
+	elapsed := time.Since(start)
+	b.StopTimer()
+	runtime.ReadMemStats(&after)
+	b.ReportMetric(float64(tokens)/elapsed.Seconds(), "tokens/s")
+	b.ReportMetric(float64(after.Mallocs-before.Mallocs)/float64(tokens), "allocs/token")
+}
+
+func TestNextTokenWithTemplates(t *testing.T) {
+	is := is2.New(t)
+	input := `"Hello ${user.name}, you have ${count + 1} items" "${ {"k": "${v}"} }" "cost: \${5} $x"`
+
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.TEMPLATE_HEAD, "Hello "},
+		{token.IDENTIFIER, "user"},
+		{token.DOT, "."},
+		{token.IDENTIFIER, "name"},
+		{token.TEMPLATE_MIDDLE, ", you have "},
+		{token.IDENTIFIER, "count"},
+		{token.PLUS, "+"},
+		{token.INT, "1"},
+		{token.TEMPLATE_TAIL, " items"},
+		{token.TEMPLATE_HEAD, ""},
+		{token.LBRACE, "{"},
+		{token.STRING, "k"},
+		{token.COLON, ":"},
+		{token.TEMPLATE_HEAD, ""},
+		{token.IDENTIFIER, "v"},
+		{token.TEMPLATE_TAIL, ""},
+		{token.RBRACE, "}"},
+		{token.TEMPLATE_TAIL, ""},
+		{token.STRING, "cost: ${5} $x"},
+		{token.EOF, ""},
+	}
+
+	lx := NewLexer(input)
+
+	for _, tt := range tests {
+		tkn := lx.NextToken()
+
+		is.Equal(tkn.Type, tt.expectedType)
+		is.Equal(tkn.Literal, tt.expectedLiteral)
+	}
+}
+
+func TestTemplatePositions(t *testing.T) {
+	is := is2.New(t)
+	lx := NewLexer(`"a${b}c"`)
+
+	head, b, tail := lx.NextToken(), lx.NextToken(), lx.NextToken()
+	is.Equal(head.Start.Column, 1)
+	is.Equal(head.End.Column, 5)
+	is.Equal(b.Start.Column, 5)
+	is.Equal(tail.Start.Column, 6)
+	is.Equal(tail.End.Column, 9)
+}
+
+func TestUnterminatedTemplates(t *testing.T) {
+	is := is2.New(t)
+	tests := []struct {
+		input         string
+		expectedError string
+	}{
+		{`x = "a ${b} c`, "1:5: unterminated string"},
+		{`x = "a ${b`, "1:5: unterminated string interpolation"},
+	}
+
+	for _, tt := range tests {
+		lx := NewLexer(tt.input)
+		lexAll(lx)
+
+		is.Equal(len(lx.Errors()), 1)
+		is.Equal(lx.Errors()[0].Error(), tt.expectedError)
+	}
+}
+
+func TestNextTokenWithRawStrings(t *testing.T) {
+	is := is2.New(t)
+	input := "let p = `C:\\new\\${dir}`;\nlet q = `a\n\"b\"`;"
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.LET, "let"},
+		{token.IDENTIFIER, "p"},
+		{token.ASSIGN, "="},
+		{token.RAW_STRING, "C:\\new\\${dir}"},
+		{token.SEMICOLON, ";"},
+		{token.LET, "let"},
+		{token.IDENTIFIER, "q"},
+		{token.ASSIGN, "="},
+		{token.RAW_STRING, "a\n\"b\""},
+		{token.SEMICOLON, ";"},
+		{token.EOF, ""},
+	}
+
+	lx := NewLexer(input)
+	for _, tt := range tests {
+		tkn := lx.NextToken()
+		is.Equal(tkn.Type, tt.expectedType)
+		is.Equal(tkn.Literal, tt.expectedLiteral)
+	}
+	is.Equal(len(lx.Errors()), 0)
+}
+
+func TestNextTokenWithRawBlocks(t *testing.T) {
+	is := is2.New(t)
+	input := "```\n  a `b` ``c``\n```;``;"
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.RAW_BLOCK, "\n  a `b` ``c``\n"},
+		{token.SEMICOLON, ";"},
+		{token.RAW_STRING, ""},
+		{token.SEMICOLON, ";"},
+		{token.EOF, ""},
+	}
+
+	lx := NewLexer(input)
+	for _, tt := range tests {
+		tkn := lx.NextToken()
+		is.Equal(tkn.Type, tt.expectedType)
+		is.Equal(tkn.Literal, tt.expectedLiteral)
+	}
+	is.Equal(len(lx.Errors()), 0)
+
+	lx = NewLexer("x = ```abc``")
+	lexAll(lx)
+	is.Equal(len(lx.Errors()), 1)
+	is.Equal(lx.Errors()[0].Error(), "1:5: unterminated raw string")
+}
+
+func TestUnterminatedRawString(t *testing.T) {
+	is := is2.New(t)
+	lx := NewLexer("x = `abc\ndef")
+	lexAll(lx)
+
+	is.Equal(len(lx.Errors()), 1)
+	is.Equal(lx.Errors()[0].Kind, UnterminatedString)
+	is.Equal(lx.Errors()[0].Error(), "1:5: unterminated raw string")
+}
+
+func TestLexerWithKeywords(t *testing.T) {
+	is := is2.New(t)
+	pt := token.DefaultKeywords()
+	pt["seja"] = token.LET
+	pt["se"] = token.IF
+	pt["senao"] = token.ELSE
+	delete(pt, "let")
+
+	lx := NewLexer("seja x = se; let", WithKeywords(pt))
+	pt["x"] = token.RETURN //the lexer keeps its own copy
+
+	expected := []token.Token{
+		{Type: token.LET, Literal: "seja"},
+		{Type: token.IDENTIFIER, Literal: "x"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.IF, Literal: "se"},
+		{Type: token.SEMICOLON, Literal: ";"},
+		{Type: token.IDENTIFIER, Literal: "let"},
+		{Type: token.EOF, Literal: ""},
+	}
+	for _, tt := range expected {
+		tkn := lx.NextToken()
+		is.Equal(tkn.Type, tt.Type)
+		is.Equal(tkn.Literal, tt.Literal)
+	}
+
+	//the default keywords are left untouched
+	is.Equal(NewLexer("seja").NextToken().Type, token.Type(token.IDENTIFIER))
+}
+
+func TestDialectsLexConcurrently(t *testing.T) {
+	is := is2.New(t)
+	config := token.Keywords{"true": token.TRUE, "false": token.FALSE}
+	input := strings.Repeat("let debug = true; fn(x) { return x; };\n", 100)
+
+	types := func(lx *Lexer) []token.Type {
+		var all []token.Type
+		for tkn := lx.NextToken(); tkn.Type != token.EOF; tkn = lx.NextToken() {
+			all = append(all, tkn.Type)
+		}
+		return all
+	}
+
+	done := make(chan []token.Type)
+	for i := 0; i < 4; i++ {
+		go func() { done <- types(NewLexer(input)) }()
+		go func() { done <- types(NewLexer(input, WithKeywords(config))) }()
+	}
+
+	keywords := map[token.Type]int{}
+	for i := 0; i < 8; i++ {
+		for _, t := range <-done {
+			keywords[t]++
+		}
+	}
+	is.Equal(keywords[token.TRUE], 800)
+	is.Equal(keywords[token.LET], 400)
+	is.Equal(keywords[token.FUNCTION], 400)
+	is.Equal(keywords[token.RETURN], 400)
+}
@@ -0,0 +1,111 @@
+package lexer
+
+import (
+	"fmt"
+	"interpreter_in_go/token"
+)
+
+//readNumber reads an integer or a float and returns which one it found.
+//Integers can be written in decimal (42), hexadecimal (0xFF), octal (0o755)
+//or binary (0b1010). Floats are decimal with a fraction, an exponent or
+//both (3.14, 1e-9, 2.5E+3). In any of them digits can be grouped with '_'
+//as in 1_000_000, as long as every '_' sits between two digits.
+//A malformed number is reported and read as ILLEGAL.
+func (l *Lexer) readNumber() token.Type {
+	start := l.position()
+	errCount := len(l.errors)
+
+	if l.char == '0' {
+		if base, name := numberBase(l.peekChar()); base != 0 {
+			l.next()
+			l.next() //skip the prefix
+			if l.readDigits(base, name) == 0 && len(l.errors) == errCount {
+				l.error(InvalidNumber, start, fmt.Sprintf("invalid number: %s literal has no digits", name))
+			}
+			return l.numberType(token.INT, errCount)
+		}
+	}
+
+	typ := token.Type(token.INT)
+	l.readDigits(10, "decimal")
+	if l.char == '.' && isDigit(l.peekChar()) {
+		typ = token.FLOAT
+		l.next()
+		l.readDigits(10, "decimal")
+	}
+	if l.char == 'e' || l.char == 'E' {
+		typ = token.FLOAT
+		l.next()
+		if l.char == '+' || l.char == '-' {
+			l.next()
+		}
+		if !isDigit(l.char) {
+			l.error(InvalidNumber, l.position(), "invalid number: exponent has no digits")
+		} else {
+			l.readDigits(10, "decimal")
+		}
+	}
+
+	//Monkey is not C, 0755 is not an octal number
+	lit := l.slice(start.Offset, l.offset())
+	if typ == token.INT && len(lit) > 1 && lit[0] == '0' && len(l.errors) == errCount {
+		l.error(InvalidNumber, start, "invalid number: leading zeros are not allowed, use 0o for octal numbers")
+	}
+
+	return l.numberType(typ, errCount)
+}
+
+//readDigits reads the digits of a number in the given base together with the '_'
+//separating them, and returns how many digits it found. Decimal digits that do
+//not belong to the base are read as well so that 0b102 is a single bad number.
+//Only the first problem of each number is reported.
+func (l *Lexer) readDigits(base int, name string) int {
+	digits := 0
+	//a '_' can also follow the base prefix as in 0x_FF
+	prevDigit := base != 10
+	ok := true
+	for isDigit(l.char) || base == 16 && isHexDigit(l.char) || l.char == '_' {
+		switch {
+		case l.char == '_':
+			next := l.peekChar()
+			if ok && (!prevDigit || !(isDigit(next) || base == 16 && isHexDigit(next))) {
+				l.error(InvalidNumber, l.position(), "invalid number: '_' must separate successive digits")
+				ok = false
+			}
+			prevDigit = false
+		case int(hexValue(l.char)) >= base:
+			if ok {
+				l.error(InvalidNumber, l.position(), fmt.Sprintf("invalid number: invalid digit %q in %s literal", l.char, name))
+				ok = false
+			}
+			prevDigit = true
+		default:
+			digits++
+			prevDigit = true
+		}
+		l.next()
+	}
+	return digits
+}
+
+//numberType returns typ unless new errors showed up while reading the number.
+func (l *Lexer) numberType(typ token.Type, errCount int) token.Type {
+	if len(l.errors) > errCount {
+		return token.ILLEGAL
+	}
+	return typ
+}
+
+//numberBase returns the base for the char after a leading '0',
+//or zero when it is not a base prefix.
+func numberBase(ch rune) (int, string) {
+	switch ch {
+	case 'x', 'X':
+		return 16, "hexadecimal"
+	case 'o', 'O':
+		return 8, "octal"
+	case 'b', 'B':
+		return 2, "binary"
+	}
+	return 0, ""
+}
@@ -0,0 +1,74 @@
+package lexer
+
+import (
+	"fmt"
+	"io"
+)
+
+//defaultBufferSize is how much input a reader lexer asks for at a time.
+const defaultBufferSize = 64 * 1024
+
+//WithBufferSize sets how many bytes a lexer created with NewReaderLexer
+//reads at a time. The lexer only holds those bytes plus the beginning of
+//the token being read, so memory use does not grow with the input.
+func WithBufferSize(n int) Option {
+	return func(l *Lexer) {
+		if n > 0 {
+			l.bufSize = n
+		}
+	}
+}
+
+//NewReaderLexer creates a Lexer that reads the source from rd as it goes,
+//instead of needing the whole program in memory. The tokens are the same
+//NewLexer would return for the same source.
+func NewReaderLexer(rd io.Reader, opts ...Option) *Lexer {
+	return newLexer(&Lexer{rd: rd, bufSize: defaultBufferSize}, opts)
+}
+
+//ensure makes at least n bytes after the current char available in
+//the input, unless the source ends before that.
+func (l *Lexer) ensure(n int) {
+	for len(l.input)-l.readPos < n && l.fill() {
+	}
+}
+
+//fill reads more of the source, dropping the input nobody needs anymore.
+//Reports false when there is nothing else to read.
+func (l *Lexer) fill() bool {
+	if l.rd == nil {
+		return false
+	}
+	if l.buf == nil {
+		l.buf = make([]byte, l.bufSize)
+	}
+
+	keep := l.mark - l.base
+	//a token longer than the buffer, like a big string, is read along
+	//with as much input again, so the window doubles in size instead
+	//of being copied over for every buffer full
+	buf := l.buf
+	if kept := len(l.input) - keep; kept > len(buf) {
+		buf = make([]byte, kept)
+	}
+
+	for {
+		n, err := l.rd.Read(buf)
+		if err != nil {
+			l.rd = nil
+			if err != io.EOF {
+				l.error(ReadFailure, l.position(), fmt.Sprintf("could not read the input: %s", err))
+			}
+		}
+		if n > 0 {
+			l.input = l.input[keep:] + string(buf[:n])
+			l.base += keep
+			l.pos -= keep
+			l.readPos -= keep
+			return true
+		}
+		if l.rd == nil {
+			return false
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package lexer
+
+import (
+	"fmt"
+	"interpreter_in_go/token"
+	"unicode/utf8"
+)
+
+//template is a string whose interpolation is being lexed.
+type template struct {
+	start token.Position //where the string begins
+	depth int            //how many '{' are open inside the interpolation
+}
+
+//stringEnd tells how readString stopped.
+type stringEnd int
+
+const (
+	closingQuote stringEnd = iota
+	interpolation
+	unterminated
+)
+
+//stringToken reads what is left of a string after its opening quote, or
+//after the '}' closing an interpolation when resumed. A string without
+//interpolations is a STRING token while "Hello ${user}, you have ${n} items"
+//is made of
+//
+//	TEMPLATE_HEAD "Hello " user TEMPLATE_MIDDLE ", you have " n TEMPLATE_TAIL " items"
+//
+//where the interpolated expressions are lexed as usual.
+func (l *Lexer) stringToken(start token.Position, resumed bool) token.Token {
+	lit, end := l.readString()
+
+	tkn := token.Token{Literal: lit}
+	switch end {
+	case interpolation:
+		if resumed {
+			tkn.Type = token.TEMPLATE_MIDDLE
+		} else {
+			tkn.Type = token.TEMPLATE_HEAD
+			l.templates = append(l.templates, template{start: start})
+		}
+	case closingQuote:
+		if resumed {
+			tkn.Type = token.TEMPLATE_TAIL
+			l.templates = l.templates[:len(l.templates)-1]
+		} else {
+			tkn.Type = token.STRING
+		}
+	case unterminated:
+		pos := start
+		if resumed {
+			//report where the whole string begins
+			pos = l.templates[len(l.templates)-1].start
+			l.templates = l.templates[:len(l.templates)-1]
+		}
+		l.error(UnterminatedString, pos, "unterminated string")
+		//there is no closing quote to skip
+		return token.Token{
+			Type:    token.ILLEGAL,
+			Literal: l.slice(start.Offset, l.offset()),
+			Start:   start,
+			End:     l.position(),
+		}
+	}
+
+	//skip the closing quote or the '{'
+	l.next()
+	tkn.Start, tkn.End = start, l.position()
+	return tkn
+}
+
+//rawStringToken reads a string between backticks. Raw strings can span
+//several lines and have no escape sequences nor interpolations: the
+//literal is exactly what is written between the backticks.
+//Three backticks open a text block, which ends at the next three
+//backticks and comes as a RAW_BLOCK token.
+func (l *Lexer) rawStringToken(start token.Position) token.Token {
+	typ, fence := token.Type(token.RAW_STRING), 1
+	if l.peekByte(0) == '`' && l.peekByte(1) == '`' {
+		typ, fence = token.RAW_BLOCK, 3
+	}
+	for i := 0; i < fence; i++ {
+		l.next() //skip the opening backticks
+	}
+	from := l.offset()
+	for l.char != '`' || fence == 3 && (l.peekByte(0) != '`' || l.peekByte(1) != '`') {
+		if l.char == eof {
+			l.error(UnterminatedString, start, "unterminated raw string")
+			return token.Token{
+				Type:    token.ILLEGAL,
+				Literal: l.slice(start.Offset, l.offset()),
+				Start:   start,
+				End:     l.position(),
+			}
+		}
+		l.next()
+	}
+	lit := l.slice(from, l.offset())
+
+	for i := 0; i < fence; i++ {
+		l.next() //skip the closing backticks
+	}
+	return token.Token{
+		Type:    typ,
+		Literal: lit,
+		Start:   start,
+		End:     l.position(),
+	}
+}
+
+//stringValue returns the string read since from, buf holding what
+//was read before the last escape sequence.
+func (l *Lexer) stringValue(buf []byte, from int) string {
+	//buf is nil unless there were escape sequences,
+	//then the value is a slice of the input.
+	if buf == nil {
+		return l.slice(from, l.offset())
+	}
+	return string(append(buf, l.slice(from, l.offset())...))
+}
+
+//readString reads the contents of a double quoted string, resolving its
+//escape sequences, until the closing quote or the `${` of an interpolation.
+//The lexer is left on the last char of whatever ended the string.
+func (l *Lexer) readString() (string, stringEnd) {
+	//buf is only needed once an escape sequence shows up,
+	//otherwise the value is a slice of the input.
+	var buf []byte
+	from := l.offset()
+	for {
+		switch l.char {
+		case '"':
+			return l.stringValue(buf, from), closingQuote
+		case '$':
+			if l.peekChar() != '{' {
+				l.next()
+				continue
+			}
+			lit := l.stringValue(buf, from)
+			l.next()
+			return lit, interpolation
+		case '\\':
+			buf = append(buf, l.slice(from, l.offset())...)
+			buf = l.readEscape(buf)
+			from = l.offset()
+		case '\n', '\r', eof:
+			return "", unterminated
+		default:
+			l.next()
+		}
+	}
+}
+
+//readEscape appends to buf the value of the escape sequence starting
+//at the current backslash and moves past it.
+func (l *Lexer) readEscape(buf []byte) []byte {
+	start := l.position()
+	l.next() //skip the backslash
+
+	switch l.char {
+	case 'n':
+		buf = append(buf, '\n')
+	case 't':
+		buf = append(buf, '\t')
+	case 'r':
+		buf = append(buf, '\r')
+	case '"':
+		buf = append(buf, '"')
+	case '$':
+		buf = append(buf, '$')
+	case '\\':
+		buf = append(buf, '\\')
+	case 'x':
+		//exactly two hex digits: \x41
+		hi, lo := rune(l.peekByte(0)), rune(l.peekByte(1))
+		if !isHexDigit(hi) || !isHexDigit(lo) {
+			l.error(InvalidEscape, start, "invalid escape sequence: \\x must be followed by two hex digits")
+			l.next()
+			return buf
+		}
+		l.next()
+		l.next()
+		buf = append(buf, hexValue(hi)<<4|hexValue(lo))
+	case 'u':
+		//between one and six hex digits inside braces: \u{1F600}
+		if l.peekChar() != '{' {
+			l.error(InvalidEscape, start, "invalid escape sequence: \\u must be followed by {")
+			l.next()
+			return buf
+		}
+		l.next()
+		l.next()
+		var r rune
+		digits := 0
+		for isHexDigit(l.char) {
+			r = r<<4 | rune(hexValue(l.char))
+			digits++
+			l.next()
+		}
+		if l.char != '}' || digits == 0 || digits > 6 {
+			l.error(InvalidEscape, start, "invalid escape sequence: \\u{...} needs between one and six hex digits")
+			if l.char == '}' {
+				l.next()
+			}
+			return buf
+		}
+		if !utf8.ValidRune(r) {
+			l.error(InvalidEscape, start, fmt.Sprintf("invalid escape sequence: %U is not a valid code point", r))
+			l.next()
+			return buf
+		}
+		var enc [utf8.UTFMax]byte
+		buf = append(buf, enc[:utf8.EncodeRune(enc[:], r)]...)
+	default:
+		if l.char == eof || l.char == '\n' {
+			//let the caller find out the string is not terminated
+			return buf
+		}
+		l.error(InvalidEscape, start, fmt.Sprintf("invalid escape sequence: \\%s", l.input[l.pos:l.readPos]))
+	}
+	l.next()
+	return buf
+}
+
@@ -0,0 +1,146 @@
+package parser
+
+import (
+	"fmt"
+	"interpreter_in_go/token"
+)
+
+//Severity tells how bad a problem is.
+type Severity int
+
+const (
+	SeverityError   Severity = iota + 1 // the program can not be run
+	SeverityWarning                     // the program is valid but looks wrong
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	}
+	return "unknown"
+}
+
+//Code tells what sort of problem the parser found.
+type Code int
+
+const (
+	LexicalError     Code = iota + 1 // reported by the lexer
+	UnexpectedToken                  // let 5 = x;
+	NoPrefixParseFn                  // a token that can not start an expression
+	InvalidLiteral                   // a number too big to fit its type
+	NotAssignable                    // 1 = 2
+	OutsideLoop                      // break or continue that is not in a loop
+	InvalidParameter                 // fn(...rest, x) { }
+	InvalidPattern                   // let [...rest, x] = xs;
+)
+
+var codeNames = map[Code]string{
+	LexicalError:     "lexical-error",
+	UnexpectedToken:  "unexpected-token",
+	NoPrefixParseFn:  "no-prefix-parse-fn",
+	InvalidLiteral:   "invalid-literal",
+	NotAssignable:    "not-assignable",
+	OutsideLoop:      "outside-loop",
+	InvalidParameter: "invalid-parameter",
+	InvalidPattern:   "invalid-pattern",
+}
+
+func (c Code) String() string {
+	if name, ok := codeNames[c]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+//Error describes a problem found while parsing.
+//Expected and Found are set when a token was not the one the grammar
+//asks for, e.g. the missing '=' of "let x 5;" is expected "=" and
+//found INT.
+type Error struct {
+	Pos      token.Position
+	Severity Severity
+	Code     Code
+	Msg      string
+	Expected token.Type
+	Found    token.Type
+}
+
+func (e Error) Error() string {
+	return e.Pos.String() + ": " + e.Msg
+}
+
+//Errors returns every problem found so far, lexer errors included,
+//in the order they were found.
+func (p *Parser) Errors() []Error {
+	return p.errors
+}
+
+//report records a problem. A syntax error puts the parser in panic
+//mode: it reports nothing else until the statement at fault has been
+//skipped (see synchronize), as whatever comes next is most likely a
+//consequence of the first error.
+func (p *Parser) report(e Error) {
+	if e.Code == LexicalError {
+		p.errors = append(p.errors, e)
+		return
+	}
+	if p.panicking {
+		return
+	}
+	if e.Severity == 0 {
+		e.Severity = SeverityError
+	}
+	p.errors = append(p.errors, e)
+	if e.Code == UnexpectedToken || e.Code == NoPrefixParseFn {
+		p.panicking = true
+		p.blockEnd = token.Position{}
+		if p.inBlock && p.curToken.Type == token.RBRACE && e.Pos == p.curToken.Start {
+			p.blockEnd = e.Pos
+		}
+	}
+}
+
+func (p *Parser) error(code Code, pos token.Position, format string, args ...interface{}) {
+	p.report(Error{
+		Pos:  pos,
+		Code: code,
+		Msg:  fmt.Sprintf(format, args...),
+	})
+}
+
+//unexpected records that tkn is not what the grammar expects at this point.
+func (p *Parser) unexpected(expected token.Type, tkn token.Token) {
+	p.report(Error{
+		Pos:      tkn.Start,
+		Code:     UnexpectedToken,
+		Msg:      fmt.Sprintf("expected %s, got %s", expected, describe(tkn)),
+		Expected: expected,
+		Found:    tkn.Type,
+	})
+}
+
+//unclosed records that the delimiter opened by open is not closed
+//by an end token where tkn is.
+func (p *Parser) unclosed(open token.Token, end token.Type, tkn token.Token) {
+	p.report(Error{
+		Pos:      tkn.Start,
+		Code:     UnexpectedToken,
+		Msg:      fmt.Sprintf("expected %s to close the %s at %s, got %s", end, open.Type, open.Start, describe(tkn)),
+		Expected: end,
+		Found:    tkn.Type,
+	})
+}
+
+//describe names a token the way it should show up in a message.
+func describe(tkn token.Token) string {
+	switch tkn.Type {
+	case token.EOF:
+		return "end of input"
+	case token.IDENTIFIER, token.INT, token.FLOAT:
+		return fmt.Sprintf("%s %q", tkn.Type, tkn.Literal)
+	}
+	return string(tkn.Type)
+}
@@ -6,6 +6,7 @@ import (
 	"interpreter_in_go/lexer"
 	"interpreter_in_go/token"
 	"strconv"
+	"strings"
 )
 
 //Parser struct represents our parser from te pov of our program.
@@ -15,7 +16,21 @@ import (
 //infix expressions or prefix expressions.
 type Parser struct {
 	lxr    *lexer.Lexer
-	errors []string
+	errors []Error
+	//how many of the lexer errors are already in errors
+	lexErrors int
+	//set from a syntax error until the parser gets back on track
+	panicking bool
+	//where the '}' that closes a block is, when the syntax error that
+	//started panic mode was found on it
+	blockEnd token.Position
+	//set when a statement with a syntax error stopped on the '}' of the
+	//enclosing block, which the block still has to read
+	held bool
+	//set while the innermost '{' still open starts a block, not a hash
+	inBlock bool
+	//how many loops enclose the current token, within the current function
+	loopDepth int
 
 	curToken  token.Token
 	peekToken token.Token
@@ -31,38 +46,70 @@ func NewParser(lxr *lexer.Lexer) *Parser {
 		lxr:            lxr,
 		curToken:       token.Token{},
 		peekToken:      token.Token{},
-		errors:         make([]string, 10),
+		errors:         []Error{},
 		infixParseFns:  make(map[token.Type]infixParseFn),
 		prefixParseFns: make(map[token.Type]prefixParseFn),
 	}
 
 	registerParsingFns(p)
 
-	p.curToken = *p.lxr.NextToken()
-	p.peekToken = *p.lxr.NextToken()
+	p.curToken = p.nextToken()
+	p.peekToken = p.nextToken()
+	p.collectLexerErrors()
 	return p
 }
 
 func registerParsingFns(p *Parser) {
 	p.registerPrefix(token.IDENTIFIER, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
+	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.RAW_STRING, p.parseRawStringLiteral)
+	p.registerPrefix(token.RAW_BLOCK, p.parseRawStringLiteral)
+	p.registerPrefix(token.TEMPLATE_HEAD, p.parseTemplateLiteral)
 
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
+	p.registerPrefix(token.INCREMENT, p.parsePrefixExpression)
+	p.registerPrefix(token.DECREMENT, p.parsePrefixExpression)
 
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.SLASH, p.parseInfixExpression)
 	p.registerInfix(token.ASTERISK, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
 	p.registerInfix(token.EQUAL, p.parseInfixExpression)
 	p.registerInfix(token.NOT_EQUAL, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.LT_EQUAL, p.parseInfixExpression)
+	p.registerInfix(token.GT_EQUAL, p.parseInfixExpression)
+	p.registerInfix(token.PIPE, p.parsePipeExpression)
+	p.registerInfix(token.QUESTION, p.parseConditionalExpression)
+	p.registerInfix(token.COALESCE, p.parseCoalesceExpression)
+	p.registerInfix(token.AND, p.parseLogicalExpression)
+	p.registerInfix(token.OR, p.parseLogicalExpression)
+
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PLUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MINUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.ASTERISK_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.SLASH_ASSIGN, p.parseAssignExpression)
+
+	p.registerInfix(token.INCREMENT, p.parsePostfixExpression)
+	p.registerInfix(token.DECREMENT, p.parsePostfixExpression)
 
 	p.registerPrefix(token.FALSE, p.parseBooleanLiteral)
 	p.registerPrefix(token.TRUE, p.parseBooleanLiteral)
 
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
+	p.registerInfix(token.LPAREN, p.parseCallExpression)
+
+	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
+	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.DOT, p.parseMemberExpression)
+
+	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
 
 	p.registerPrefix(token.IF, p.parseIfExpression)
 
@@ -90,55 +137,302 @@ func (p *Parser) ParseProgram() *ast.Root {
 
 //ParseStatement decides which kind of parsing method to
 // apply based on the type of current token.
+//A '{' that starts a statement opens a block, in any other place
+//it starts a hash: `{ x }` is a block while `let h = { x: 1 };`
+//and `({ x: 1 })` are hashes.
+//A statement with a syntax error is skipped and
+//comes back as an *ast.BadStatement.
 func (p *Parser) ParseStatement() ast.Statement {
+	start := p.curToken
+	var stmt ast.Statement
+	p.held = false
 
 	switch p.curToken.Type {
 	case token.LET:
-		return p.parseLetStatement()
+		if s := p.parseLetStatement(); s != nil {
+			stmt = s
+		}
 	case token.RETURN:
-		return p.parseReturnStatement()
+		if s := p.parseReturnStatement(); s != nil {
+			stmt = s
+		}
+	case token.LBRACE:
+		if s := p.parseBlockStatement(); s != nil {
+			stmt = s
+		}
+	case token.WHILE:
+		if s := p.parseWhileStatement(); s != nil {
+			stmt = s
+		}
+	case token.FOR:
+		stmt = p.parseForStatement()
+	case token.BREAK, token.CONTINUE:
+		stmt = p.parseJumpStatement()
 	default:
-		return p.parseExpressionStatement()
+		stmt = p.parseExpressionStatement()
+	}
+
+	switch stmt.(type) {
+	case *ast.BlockStatement, *ast.WhileStatement, *ast.ForStatement, *ast.ForInStatement:
+		//like any other statement, one that ends with a block may be followed by ';'
+		if !p.panicking && p.peekToken.Type == token.SEMICOLON {
+			p.ReadToken()
+		}
+	}
+
+	if p.panicking || stmt == nil {
+		p.synchronize()
+		if p.held {
+			return &ast.BadStatement{Token: start, End: p.curToken.Start}
+		}
+		return &ast.BadStatement{Token: start, End: p.curToken.End}
+	}
+	return stmt
+}
+
+//synchronize skips the tokens of a statement with a syntax error.
+//It stops at the ';' that ends the statement or right before a '}',
+//a keyword that starts a new statement or the end of the input,
+//so the caller goes on from there as after any other statement.
+//A '{' skipped on the way is skipped up to its '}', whatever is in
+//between, as in `if (a { 1 }; let q = 2;`.
+//When the error is found on the '}' that closes the enclosing block,
+//as in `fn() { let x = }`, that '}' is left where it is and held is set
+//so the block does not read past it.
+func (p *Parser) synchronize() {
+	p.panicking = false
+	if p.curToken.Type == token.RBRACE && p.curToken.Start == p.blockEnd {
+		p.held = true
+		return
+	}
+	//how many of the '{' skipped are still open
+	depth := 0
+	for p.curToken.Type != token.EOF {
+		switch p.curToken.Type {
+		case token.LBRACE:
+			depth++
+		case token.RBRACE:
+			if depth > 0 {
+				depth--
+			}
+		case token.SEMICOLON:
+			if depth == 0 {
+				return
+			}
+		}
+		if depth == 0 {
+			switch p.peekToken.Type {
+			case token.RBRACE, token.LET, token.RETURN, token.WHILE, token.FOR,
+				token.BREAK, token.CONTINUE, token.EOF:
+				return
+			}
+		}
+		p.ReadToken()
 	}
 }
 
 //parseLetStatement parses a statement of the type let.
 //let x = 9;
 //let myFn = sum;
+//let [first, ...others] = xs;
 func (p *Parser) parseLetStatement() *ast.LetStatement {
-	//validate that variable name comes after 'LET'
-	if p.curToken.Type == token.LET && p.peekToken.Type != token.IDENTIFIER {
-		return nil
-	}
-
 	stmt := &ast.LetStatement{
 		Token: p.curToken,
-		Name: &ast.IdentifierStatement{
-			Token: p.peekToken,
-			Value: p.peekToken.Literal,
-		},
 		Value: nil,
 	}
 
-	//validate that '=' comes after variable name
-	if p.curToken.Type == token.IDENTIFIER && p.peekToken.Type != token.ASSIGN {
+	//validate that variable name or pattern comes after 'LET'
+	p.ReadToken()
+	stmt.Name = p.parsePattern()
+	if stmt.Name == nil {
 		return nil
 	}
+	p.checkPatternNames(stmt.Name)
 
-	//get to the right part of the let statement
-	for p.curToken.Type != token.ASSIGN {
-		p.ReadToken()
+	//validate that '=' comes after variable name
+	if !p.expectPeek(token.ASSIGN) {
+		return nil
 	}
 	p.ReadToken()
 
 	//parse the right side
 	stmt.Value = p.parseExpression(LOWEST)
 
+	if !p.panicking && p.peekToken.Type == token.SEMICOLON {
+		p.ReadToken()
+	}
+
 	return stmt
 }
 
+//parsePattern parses what a let statement binds its value to.
+//let x = ...
+//let [a, b = 2, ...rest] = ...
+//let {name, age: years, "first name": first} = ...
+func (p *Parser) parsePattern() ast.Pattern {
+	switch p.curToken.Type {
+	case token.IDENTIFIER:
+		return &ast.IdentifierStatement{Token: p.curToken, Value: p.curToken.Literal}
+	case token.LBRACKET:
+		return p.parseArrayPattern()
+	case token.LBRACE:
+		return p.parseHashPattern()
+	}
+	p.unexpected(token.IDENTIFIER, p.curToken)
+	return nil
+}
+
+//parseDefault parses the '= value' that may follow a pattern nested
+//in another one.
+func (p *Parser) parseDefault(target ast.Pattern) ast.Pattern {
+	if p.peekToken.Type != token.ASSIGN {
+		return target
+	}
+	p.ReadToken()
+	pattern := &ast.DefaultPattern{Token: p.curToken, Target: target}
+	p.ReadToken()
+	pattern.Default = p.parseExpression(ASSIGN)
+	return pattern
+}
+
+func (p *Parser) parseArrayPattern() ast.Pattern {
+	pattern := &ast.ArrayPattern{
+		Token:    p.curToken,
+		Elements: []ast.Pattern{},
+	}
+
+	for p.peekToken.Type != token.RBRACKET {
+		p.ReadToken()
+
+		if n := len(pattern.Elements); n > 0 {
+			if rest, ok := pattern.Elements[n-1].(*ast.RestPattern); ok {
+				p.error(InvalidPattern, rest.Token.Start, "rest element %s must be the last one", rest)
+			}
+		}
+
+		if p.curToken.Type == token.ELLIPSIS {
+			rest := &ast.RestPattern{Token: p.curToken}
+			if !p.expectPeek(token.IDENTIFIER) {
+				return nil
+			}
+			rest.Target = &ast.IdentifierStatement{Token: p.curToken, Value: p.curToken.Literal}
+			if p.peekToken.Type == token.ASSIGN {
+				p.error(InvalidPattern, p.peekToken.Start, "rest element %s can not have a default value", rest)
+				p.parseDefault(rest)
+			}
+			pattern.Elements = append(pattern.Elements, rest)
+		} else {
+			element := p.parsePattern()
+			if element == nil {
+				return nil
+			}
+			pattern.Elements = append(pattern.Elements, p.parseDefault(element))
+		}
+
+		if p.peekToken.Type != token.COMMA {
+			break
+		}
+		p.ReadToken()
+	}
+
+	if p.peekToken.Type != token.RBRACKET {
+		p.unclosed(pattern.Token, token.RBRACKET, p.peekToken)
+		return nil
+	}
+	p.ReadToken()
+
+	return pattern
+}
+
+func (p *Parser) parseHashPattern() ast.Pattern {
+	pattern := &ast.HashPattern{
+		Token: p.curToken,
+		Pairs: []ast.HashPatternPair{},
+	}
+
+	inBlock := p.inBlock
+	p.inBlock = false
+	defer func() { p.inBlock = inBlock }()
+
+	for p.peekToken.Type != token.RBRACE {
+		p.ReadToken()
+
+		var pair ast.HashPatternPair
+		switch p.curToken.Type {
+		case token.IDENTIFIER:
+			pair.Key = &ast.IdentifierStatement{Token: p.curToken, Value: p.curToken.Literal}
+		case token.STRING:
+			pair.Key = &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+		default:
+			p.unexpected(token.IDENTIFIER, p.curToken)
+			return nil
+		}
+
+		switch {
+		case p.peekToken.Type == token.COLON:
+			p.ReadToken()
+			p.ReadToken()
+			value := p.parsePattern()
+			if value == nil {
+				return nil
+			}
+			pair.Value = p.parseDefault(value)
+		case p.curToken.Type == token.IDENTIFIER:
+			//{name} is short for {name: name}
+			pair.Value = p.parseDefault(&ast.IdentifierStatement{Token: p.curToken, Value: p.curToken.Literal})
+		default:
+			p.unexpected(token.COLON, p.peekToken)
+			return nil
+		}
+		pattern.Pairs = append(pattern.Pairs, pair)
+
+		if p.peekToken.Type != token.COMMA {
+			break
+		}
+		p.ReadToken()
+	}
+
+	if p.peekToken.Type != token.RBRACE {
+		p.unclosed(pattern.Token, token.RBRACE, p.peekToken)
+		return nil
+	}
+	p.ReadToken()
+
+	return pattern
+}
+
+//checkPatternNames reports a name bound twice by the same pattern.
+func (p *Parser) checkPatternNames(pattern ast.Pattern) {
+	seen := map[string]bool{}
+
+	var walk func(ast.Pattern)
+	walk = func(pattern ast.Pattern) {
+		switch pt := pattern.(type) {
+		case *ast.IdentifierStatement:
+			if seen[pt.Value] {
+				p.error(InvalidPattern, pt.Token.Start, "%s is bound more than once", pt.Value)
+			}
+			seen[pt.Value] = true
+		case *ast.ArrayPattern:
+			for _, e := range pt.Elements {
+				walk(e)
+			}
+		case *ast.HashPattern:
+			for _, pair := range pt.Pairs {
+				walk(pair.Value)
+			}
+		case *ast.DefaultPattern:
+			walk(pt.Target)
+		case *ast.RestPattern:
+			walk(pt.Target)
+		}
+	}
+	walk(pattern)
+}
+
 //parseReturnStatement parses a statement of the type return.
 //return <something>;
+//A bare return, as in `return;` or `fn() { return }`, has no value.
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	//validate we have found a return statement
 	if p.curToken.Type != token.RETURN {
@@ -150,9 +444,166 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 		ReturnValue: nil,
 	}
 
-	p.ReadToken()
-	for p.curToken.Literal != token.SEMICOLON {
+	switch p.peekToken.Type {
+	case token.SEMICOLON, token.RBRACE, token.EOF:
+	default:
+		p.ReadToken()
 		stmt.ReturnValue = p.parseExpression(LOWEST)
+	}
+
+	if !p.panicking && p.peekToken.Type == token.SEMICOLON {
+		p.ReadToken()
+	}
+
+	return stmt
+}
+
+//parseWhileStatement parses a loop that runs while its condition holds.
+//while (x < 10) { x += 1; }
+func (p *Parser) parseWhileStatement() *ast.WhileStatement {
+	stmt := &ast.WhileStatement{
+		Token: p.curToken,
+	}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	p.ReadToken()
+	stmt.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) || !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+	if stmt.Body == nil {
+		return nil
+	}
+
+	return stmt
+}
+
+//parseForStatement parses both kinds of for loops. When the '(' is
+//followed by one or two names and 'in', it is a for-in loop, otherwise
+//it is the C-style loop, whose three parts may be left out.
+//for (let i = 0; i < n; i++) { ... }
+//for (x in xs) { ... }
+func (p *Parser) parseForStatement() ast.Statement {
+	stmt := &ast.ForStatement{
+		Token: p.curToken,
+	}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	//the first part ends at its ';'
+	switch p.peekToken.Type {
+	case token.SEMICOLON:
+		p.ReadToken()
+	case token.LET:
+		p.ReadToken()
+		init := p.parseLetStatement()
+		if init == nil {
+			return nil
+		}
+		stmt.Init = init
+	default:
+		p.ReadToken()
+		if p.curToken.Type == token.IDENTIFIER &&
+			(p.peekToken.Type == token.IN || p.peekToken.Type == token.COMMA) {
+			return p.parseForInStatement(stmt.Token)
+		}
+		stmt.Init = p.parseExpressionStatement()
+	}
+	if p.curToken.Type != token.SEMICOLON {
+		p.unexpected(token.SEMICOLON, p.peekToken)
+		return nil
+	}
+
+	if p.peekToken.Type != token.SEMICOLON {
+		p.ReadToken()
+		stmt.Condition = p.parseExpression(LOWEST)
+	}
+	if !p.expectPeek(token.SEMICOLON) {
+		return nil
+	}
+
+	if p.peekToken.Type != token.RPAREN {
+		p.ReadToken()
+		stmt.Post = p.parseExpression(LOWEST)
+	}
+	if !p.expectPeek(token.RPAREN) || !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+	if stmt.Body == nil {
+		return nil
+	}
+
+	return stmt
+}
+
+//parseForInStatement parses a for-in loop from its first name on.
+//for (k, v in h) { ... }
+func (p *Parser) parseForInStatement(tkn token.Token) ast.Statement {
+	stmt := &ast.ForInStatement{
+		Token: tkn,
+		Value: &ast.IdentifierStatement{Token: p.curToken, Value: p.curToken.Literal},
+	}
+
+	if p.peekToken.Type == token.COMMA {
+		p.ReadToken()
+		if !p.expectPeek(token.IDENTIFIER) {
+			return nil
+		}
+		stmt.Key = stmt.Value
+		stmt.Value = &ast.IdentifierStatement{Token: p.curToken, Value: p.curToken.Literal}
+	}
+
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+	p.ReadToken()
+	stmt.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) || !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+	if stmt.Body == nil {
+		return nil
+	}
+
+	return stmt
+}
+
+//parseLoopBody parses the block of a loop, where break and continue
+//are allowed.
+func (p *Parser) parseLoopBody() *ast.BlockStatement {
+	p.loopDepth++
+	defer func() { p.loopDepth-- }()
+
+	return p.parseBlockStatement()
+}
+
+//parseJumpStatement parses break and continue, which only make sense
+//inside of a loop.
+func (p *Parser) parseJumpStatement() ast.Statement {
+	var stmt ast.Statement
+	if p.curToken.Type == token.BREAK {
+		stmt = &ast.BreakStatement{Token: p.curToken}
+	} else {
+		stmt = &ast.ContinueStatement{Token: p.curToken}
+	}
+
+	if p.loopDepth == 0 {
+		p.error(OutsideLoop, p.curToken.Start, "%s outside of a loop", p.curToken.Literal)
+	}
+
+	if p.peekToken.Type == token.SEMICOLON {
 		p.ReadToken()
 	}
 
@@ -169,7 +620,7 @@ func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 
 	//if next token is "the end", read it.
 	//having ';' is optional for something like 5 + 5 to work well.
-	if p.peekToken.Literal == token.SEMICOLON {
+	if !p.panicking && p.peekToken.Literal == token.SEMICOLON {
 		p.ReadToken()
 	}
 
@@ -189,28 +640,154 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	}
 	literal, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.error(InvalidLiteral, p.curToken.Start, "could not parse %q as integer", p.curToken.Literal)
 		return nil
 	}
 	il.Value = literal
 	return il
 }
 
-func (p *Parser) parseBooleanLiteral() ast.Expression {
-	b := &ast.BooleanLiteral{
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	fl := &ast.FloatLiteral{
 		Token: p.curToken,
 	}
-
-	if val, err := strconv.ParseBool(p.curToken.Literal); err == nil {
-		b.Value = val
-	} else {
-		msg := fmt.Sprintf("could not parse %s as boolean", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+	literal, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		p.error(InvalidLiteral, p.curToken.Start, "could not parse %q as float", p.curToken.Literal)
 		return nil
 	}
+	fl.Value = literal
+	return fl
+}
+
+func (p *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{
+		Token: p.curToken,
+		Value: p.curToken.Literal,
+	}
+}
+
+//parseRawStringLiteral parses a string between backticks, whose value
+//is its literal, or a text block between triple backticks, whose
+//indentation is stripped.
+func (p *Parser) parseRawStringLiteral() ast.Expression {
+	str := &ast.StringLiteral{
+		Token: p.curToken,
+		Value: p.curToken.Literal,
+		Raw:   true,
+	}
+	if p.curToken.Type == token.RAW_BLOCK {
+		str.Value = unindent(str.Value)
+	}
+	return str
+}
+
+//unindent returns the value of a text block. When the opening backticks
+//are followed by a line break, the block is indented like
+//
+//	let query = ```
+//	    SELECT *
+//	    FROM users
+//	```;
+//
+//and the first line break is dropped, as well as the indentation shared
+//by all lines that are not blank and a last line made only of spaces or
+//tabs. The value of query is "SELECT *\nFROM users\n".
+//A block on a single line is taken as it is.
+func unindent(raw string) string {
+	var body string
+	switch {
+	case strings.HasPrefix(raw, "\n"):
+		body = raw[1:]
+	case strings.HasPrefix(raw, "\r\n"):
+		body = raw[2:]
+	default:
+		return raw
+	}
+
+	lines := strings.SplitAfter(body, "\n")
+	if last := lines[len(lines)-1]; strings.Trim(last, " \t") == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	indent := ""
+	found := false
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		lead := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if !found {
+			indent, found = lead, true
+			continue
+		}
+		for !strings.HasPrefix(lead, indent) {
+			indent = indent[:len(indent)-1]
+		}
+	}
+
+	var out strings.Builder
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			//keep the line break only
+			out.WriteString(line[len(strings.TrimRight(line, "\r\n")):])
+			continue
+		}
+		out.WriteString(line[len(indent):])
+	}
+	return out.String()
+}
+
+//parseTemplateLiteral parses a string with interpolations.
+//"Hello ${name}!" comes from the lexer as TEMPLATE_HEAD, the tokens of
+//the expression and TEMPLATE_TAIL, with TEMPLATE_MIDDLE between two
+//expressions. Each expression is parsed as any other expression.
+func (p *Parser) parseTemplateLiteral() ast.Expression {
+	tl := &ast.TemplateLiteral{
+		Token: p.curToken,
+		Texts: []string{p.curToken.Literal},
+	}
+
+	for {
+		if p.peekToken.Type == token.TEMPLATE_MIDDLE || p.peekToken.Type == token.TEMPLATE_TAIL {
+			p.report(Error{
+				Pos:      p.peekToken.Start,
+				Code:     UnexpectedToken,
+				Msg:      "empty expression in string interpolation",
+				Expected: token.EXPRESSION,
+				Found:    p.peekToken.Type,
+			})
+			return nil
+		}
+		p.ReadToken()
+		tl.Expressions = append(tl.Expressions, p.parseExpression(LOWEST))
+
+		p.ReadToken()
+		switch p.curToken.Type {
+		case token.TEMPLATE_MIDDLE:
+			tl.Texts = append(tl.Texts, p.curToken.Literal)
+		case token.TEMPLATE_TAIL:
+			tl.Texts = append(tl.Texts, p.curToken.Literal)
+			return tl
+		default:
+			p.report(Error{
+				Pos:      p.curToken.Start,
+				Code:     UnexpectedToken,
+				Msg:      fmt.Sprintf("expected } to close the string interpolation, got %s", p.curToken.Type),
+				Expected: token.TEMPLATE_TAIL,
+				Found:    p.curToken.Type,
+			})
+			return nil
+		}
+	}
+}
 
-	return b
+func (p *Parser) parseBooleanLiteral() ast.Expression {
+	//the token type tells the value, as a dialect may spell true differently
+	return &ast.BooleanLiteral{
+		Token: p.curToken,
+		Value: p.curToken.Type == token.TRUE,
+	}
 }
 
 func (p *Parser) parsePrefixExpression() ast.Expression {
@@ -222,6 +799,9 @@ func (p *Parser) parsePrefixExpression() ast.Expression {
 	p.ReadToken() //start what might be an expression
 
 	prefix.Right = p.parseExpression(PREFIX)
+	if prefix.Token.Type == token.INCREMENT || prefix.Token.Type == token.DECREMENT {
+		p.checkAssignable(prefix.Right, prefix.Token)
+	}
 
 	return prefix
 }
@@ -240,9 +820,168 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return exp
 }
 
+//parseLogicalExpression parses '&&' and '||', which evaluate their
+//right side only when the left one does not decide the result.
+func (p *Parser) parseLogicalExpression(left ast.Expression) ast.Expression {
+	exp := &ast.LogicalExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+		Left:     left,
+	}
+
+	precedence := p.currentPrecedence()
+	p.ReadToken()
+	exp.Right = p.parseExpression(precedence)
+
+	return exp
+}
+
+//parsePipeExpression parses the function a value is piped into.
+//xs |> filter(isEven) |> map(double)
+func (p *Parser) parsePipeExpression(left ast.Expression) ast.Expression {
+	exp := &ast.PipeExpression{
+		Token: p.curToken,
+		Left:  left,
+	}
+
+	precedence := p.currentPrecedence()
+	p.ReadToken()
+	exp.Right = p.parseExpression(precedence)
+
+	return exp
+}
+
+//parseConditionalExpression parses what follows the '?' of 'c ? a : b'.
+//It groups from the right: a ? b : c ? d : e is a ? b : (c ? d : e).
+func (p *Parser) parseConditionalExpression(condition ast.Expression) ast.Expression {
+	exp := &ast.ConditionalExpression{
+		Token:     p.curToken,
+		Condition: condition,
+	}
+
+	p.ReadToken()
+	exp.Consequence = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.COLON) {
+		return nil
+	}
+	p.ReadToken()
+	exp.Alternative = p.parseExpression(TERNARY - 1)
+
+	return exp
+}
+
+//parseCoalesceExpression parses 'a ?? b', which groups from the right.
+func (p *Parser) parseCoalesceExpression(left ast.Expression) ast.Expression {
+	exp := &ast.CoalesceExpression{
+		Token: p.curToken,
+		Left:  left,
+	}
+
+	p.ReadToken()
+	exp.Right = p.parseExpression(COALESCE - 1)
+
+	return exp
+}
+
+//parseAssignExpression parses '=' and the compound assignments.
+//They group from the right: a = b += c is a = (b += c).
+//Only identifiers, indexes and members can be assigned to.
+func (p *Parser) parseAssignExpression(left ast.Expression) ast.Expression {
+	exp := &ast.AssignExpression{
+		Token:    p.curToken,
+		Target:   left,
+		Operator: p.curToken.Literal,
+	}
+
+	p.checkAssignable(left, exp.Token)
+
+	precedence := p.currentPrecedence()
+	p.ReadToken()
+	exp.Value = p.parseExpression(precedence - 1)
+
+	return exp
+}
+
+//checkAssignable reports an error when the target of op, an assignment
+//or a ++ or --, is not a name, an index or a field.
+func (p *Parser) checkAssignable(target ast.Expression, op token.Token) {
+	switch target.(type) {
+	case *ast.IdentifierStatement, *ast.IndexExpression, *ast.MemberExpression:
+	case *ast.BadExpression: //already reported
+	default:
+		p.error(NotAssignable, op.Start, "cannot assign to %s", target.String())
+	}
+}
+
+//parseMemberExpression parses the field name after a '.'.
+//user.name
+func (p *Parser) parseMemberExpression(object ast.Expression) ast.Expression {
+	exp := &ast.MemberExpression{
+		Token:  p.curToken,
+		Object: object,
+	}
+
+	if !p.expectPeek(token.IDENTIFIER) {
+		return nil
+	}
+	exp.Property = &ast.IdentifierStatement{
+		Token: p.curToken,
+		Value: p.curToken.Literal,
+	}
+
+	return exp
+}
+
+func (p *Parser) parsePostfixExpression(left ast.Expression) ast.Expression {
+	p.checkAssignable(left, p.curToken)
+	return &ast.PostfixExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+		Left:     left,
+	}
+}
+
+//expectPeek reads the next token when it has the given type.
+//Otherwise it records an error and leaves the tokens as they are.
+func (p *Parser) expectPeek(t token.Type) bool {
+	if p.peekToken.Type != t {
+		p.unexpected(t, p.peekToken)
+		return false
+	}
+	p.ReadToken()
+	return true
+}
+
 func (p *Parser) ReadToken() {
 	p.curToken = p.peekToken
-	p.peekToken = *p.lxr.NextToken()
+	p.peekToken = p.nextToken()
+	p.collectLexerErrors()
+}
+
+//nextToken returns the next token of the lexer that is not a comment,
+//as a lexer created with lexer.WithComments returns them too.
+func (p *Parser) nextToken() token.Token {
+	tkn := p.lxr.NextToken()
+	for tkn.Type == token.COMMENT {
+		tkn = p.lxr.NextToken()
+	}
+	return tkn
+}
+
+//collectLexerErrors adds the errors the lexer found since the last call
+//to our own, so they show up in the same order as the source.
+func (p *Parser) collectLexerErrors() {
+	errs := p.lxr.Errors()
+	for _, err := range errs[p.lexErrors:] {
+		p.report(Error{
+			Pos:      err.Pos,
+			Severity: SeverityError,
+			Code:     LexicalError,
+			Msg:      err.Msg,
+		})
+	}
+	p.lexErrors = len(errs)
 }
 
 //This constants help the parser understand the rule of
@@ -250,23 +989,49 @@ func (p *Parser) ReadToken() {
 const (
 	_ int = iota
 	LOWEST
+	ASSIGN      // x = 1 or x += 1
+	PIPE        // xs |> f
+	TERNARY     // c ? a : b
+	COALESCE    // a ?? b
+	LOGICAL_OR  // ||
+	LOGICAL_AND // &&
 	EQUALS      // ==
 	LESSGREATER // > or <
 	SUM         // +
 	PRODUCT     // *
 	PREFIX      // -X or !X
+	POSTFIX     // X++
 	CALL        // myFunction(X)
+	INDEX       // xs[0]
 )
 
 var precedences = map[token.Type]int{
-	token.EQUAL:     EQUALS,
-	token.NOT_EQUAL: EQUALS,
-	token.LT:        LESSGREATER,
-	token.GT:        LESSGREATER,
-	token.PLUS:      SUM,
-	token.MINUS:     SUM,
-	token.SLASH:     PRODUCT,
-	token.ASTERISK:  PRODUCT,
+	token.ASSIGN:          ASSIGN,
+	token.PLUS_ASSIGN:     ASSIGN,
+	token.MINUS_ASSIGN:    ASSIGN,
+	token.ASTERISK_ASSIGN: ASSIGN,
+	token.SLASH_ASSIGN:    ASSIGN,
+	token.PIPE:            PIPE,
+	token.QUESTION:        TERNARY,
+	token.COALESCE:        COALESCE,
+	token.OR:              LOGICAL_OR,
+	token.AND:             LOGICAL_AND,
+	token.EQUAL:           EQUALS,
+	token.NOT_EQUAL:       EQUALS,
+	token.LT:              LESSGREATER,
+	token.GT:              LESSGREATER,
+	token.LT_EQUAL:        LESSGREATER,
+	token.GT_EQUAL:        LESSGREATER,
+	token.PLUS:            SUM,
+	token.MINUS:           SUM,
+	token.SLASH:           PRODUCT,
+	token.ASTERISK:        PRODUCT,
+	token.PERCENT:         PRODUCT,
+	token.INCREMENT:       POSTFIX,
+	token.DECREMENT:       POSTFIX,
+	token.LPAREN:          CALL,
+	token.LBRACKET:        INDEX,
+	token.DOT:             INDEX,
 }
 
 type (
@@ -283,11 +1048,19 @@ func (p *Parser) registerInfix(tokenType token.Type, fn infixParseFn) {
 }
 
 func (p *Parser) parseExpression(precedence int) ast.Expression {
+	start := p.curToken
 	prefix := p.prefixParseFns[p.curToken.Type]
 	if prefix == nil {
-		return nil
+		//the lexer already told what is wrong with an ILLEGAL token
+		if p.curToken.Type != token.ILLEGAL {
+			p.error(NoPrefixParseFn, p.curToken.Start, "no prefix parse function for %s", describe(p.curToken))
+		}
+		return &ast.BadExpression{Token: start}
 	}
 	leftExpression := prefix()
+	if leftExpression == nil {
+		return &ast.BadExpression{Token: start}
+	}
 
 	for !(p.peekToken.Type == token.SEMICOLON) && precedence < p.peekPrecedence() {
 		infix := p.infixParseFns[p.peekToken.Type]
@@ -296,6 +1069,9 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 		}
 		p.ReadToken()
 		leftExpression = infix(leftExpression)
+		if leftExpression == nil {
+			return &ast.BadExpression{Token: start}
+		}
 	}
 
 	return leftExpression
@@ -316,51 +1092,211 @@ func (p *Parser) currentPrecedence() int {
 }
 
 func (p *Parser) parseGroupedExpression() ast.Expression {
+	open := p.curToken
 	p.ReadToken()
 
 	exp := p.parseExpression(LOWEST)
 
 	if p.peekToken.Type != token.RPAREN {
+		p.unclosed(open, token.RPAREN, p.peekToken)
+		return nil
+	}
+	p.ReadToken()
+
+	return exp
+}
+
+//parseCallExpression parses the arguments of a call to the function
+//that comes before the '('.
+//add(1, 2 * 3)
+//fn(x) { x }(5)
+func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
+	call := &ast.CallExpression{
+		Token:    p.curToken,
+		Function: function,
+	}
+
+	call.Arguments = p.parseExpressionList(token.RPAREN, true)
+	if call.Arguments == nil {
+		return nil
+	}
+
+	return call
+}
+
+//parseExpressionList parses expressions separated by commas up to the
+//end token, which closes the list opened by the current token.
+//A comma may follow the last expression: (1, 2,).
+//With spread, an expression may come after '...', as in f(x, ...args).
+func (p *Parser) parseExpressionList(end token.Type, spread bool) []ast.Expression {
+	open := p.curToken
+	list := []ast.Expression{}
+
+	for p.peekToken.Type != end {
+		p.ReadToken()
+		if spread && p.curToken.Type == token.ELLIPSIS {
+			exp := &ast.SpreadExpression{Token: p.curToken}
+			p.ReadToken()
+			exp.Value = p.parseExpression(LOWEST)
+			list = append(list, exp)
+		} else {
+			list = append(list, p.parseExpression(LOWEST))
+		}
+
+		if p.peekToken.Type != token.COMMA {
+			break
+		}
+		p.ReadToken()
+	}
+
+	if p.peekToken.Type != end {
+		p.unclosed(open, end, p.peekToken)
+		return nil
+	}
+	p.ReadToken()
+
+	return list
+}
+
+func (p *Parser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{
+		Token: p.curToken,
+	}
+
+	array.Elements = p.parseExpressionList(token.RBRACKET, false)
+	if array.Elements == nil {
+		return nil
+	}
+
+	return array
+}
+
+//parseIndexExpression parses what comes after the '[' that follows
+//an expression. It is either an index or, with a ':', a slice whose
+//bounds may be left out.
+//xs[1]
+//xs[1:3]
+//xs[:2]
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	open := p.curToken
+
+	var index ast.Expression
+	if p.peekToken.Type != token.COLON {
+		p.ReadToken()
+		index = p.parseExpression(LOWEST)
+	}
+
+	var exp ast.Expression
+	if p.peekToken.Type == token.COLON {
+		p.ReadToken()
+		slice := &ast.SliceExpression{Token: open, Left: left, Low: index}
+		if p.peekToken.Type != token.RBRACKET {
+			p.ReadToken()
+			slice.High = p.parseExpression(LOWEST)
+		}
+		exp = slice
+	} else {
+		exp = &ast.IndexExpression{Token: open, Left: left, Index: index}
+	}
+
+	if p.peekToken.Type != token.RBRACKET {
+		p.unclosed(open, token.RBRACKET, p.peekToken)
 		return nil
 	}
+	p.ReadToken()
 
 	return exp
 }
 
+//parseHashLiteral parses the key: value pairs of a hash.
+//Keys and values can be any expression and a comma may follow
+//the last pair.
+//{"name": "Thorsten", "age": 28,}
+func (p *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{
+		Token: p.curToken,
+		Pairs: []ast.HashPair{},
+	}
+
+	inBlock := p.inBlock
+	p.inBlock = false
+	defer func() { p.inBlock = inBlock }()
+
+	for p.peekToken.Type != token.RBRACE {
+		p.ReadToken()
+		key := p.parseExpression(LOWEST)
+
+		if !p.expectPeek(token.COLON) {
+			return nil
+		}
+		p.ReadToken()
+		value := p.parseExpression(LOWEST)
+
+		hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: value})
+
+		if p.peekToken.Type != token.COMMA {
+			break
+		}
+		p.ReadToken()
+	}
+
+	if p.peekToken.Type != token.RBRACE {
+		p.unclosed(hash.Token, token.RBRACE, p.peekToken)
+		return nil
+	}
+	p.ReadToken()
+
+	return hash
+}
+
 func (p *Parser) parseIfExpression() ast.Expression {
 	ifExp := &ast.IfExpression{
 		Token: p.curToken,
 	}
 
 	//validate correct syntax
-	if p.peekToken.Type != token.LPAREN {
+	if !p.expectPeek(token.LPAREN) {
 		return nil
 	}
 	p.ReadToken()
 	ifExp.Condition = p.parseExpression(LOWEST)
 
-	if p.peekToken.Type != token.RPAREN {
-		return nil
-	} else {
-		p.ReadToken()
-	}
-	if p.peekToken.Type != token.LBRACE {
+	if !p.expectPeek(token.RPAREN) || !p.expectPeek(token.LBRACE) {
 		return nil
-	} else {
-		p.ReadToken()
 	}
 
 	ifExp.Consequence = p.parseBlockStatement()
+	if ifExp.Consequence == nil {
+		return nil
+	}
 
 	//support the 'else' clause
-	p.ReadToken()
 	if p.peekToken.Type != token.ELSE {
+		return ifExp
+	}
+	p.ReadToken()
+
+	//else if (...) { } is an alternative with just the next if in it
+	if p.peekToken.Type == token.IF {
 		p.ReadToken()
-		if p.peekToken.Type != token.LBRACE {
+		elseIf := p.curToken
+		next := p.parseIfExpression()
+		if next == nil {
 			return nil
 		}
+		ifExp.Alternative = &ast.BlockStatement{
+			Token:      elseIf,
+			Statements: []ast.Statement{&ast.ExpressionStatement{Token: elseIf, Expression: next}},
+		}
+		return ifExp
+	}
 
-		ifExp.Alternative = p.parseBlockStatement()
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	ifExp.Alternative = p.parseBlockStatement()
+	if ifExp.Alternative == nil {
+		return nil
 	}
 
 	return ifExp
@@ -374,20 +1310,24 @@ func (p *Parser) parseFunctionExpression() ast.Expression {
 		Token: p.curToken,
 	}
 
-	if p.peekToken.Literal != token.LPAREN {
+	if !p.expectPeek(token.LPAREN) {
 		return nil
-	}else{
-		//advance
-		p.ReadToken()
 	}
 
 	funcExp.Parameters = p.parseFunctionParameters()
+	if funcExp.Parameters == nil {
+		return nil
+	}
 
-	if p.peekToken.Literal != token.LBRACE {
+	if !p.expectPeek(token.LBRACE) {
 		return nil
 	}
 
+	//a loop around the function does not reach its body
+	depth := p.loopDepth
+	p.loopDepth = 0
 	funcExp.Body = p.parseBlockStatement()
+	p.loopDepth = depth
 
 	return funcExp
 }
@@ -398,16 +1338,25 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	}
 	block.Statements = []ast.Statement{}
 
-	//advance until the bock statement starts
-	for p.curToken.Type != token.LBRACE {
-		p.ReadToken()
-	}
+	inBlock := p.inBlock
+	p.inBlock = true
+	defer func() { p.inBlock = inBlock }()
+
+	//skip the '{' that starts the block
 	p.ReadToken()
 
 	//parse clause until the end
 	for p.curToken.Type != token.RBRACE {
+		if p.curToken.Type == token.EOF {
+			p.unexpected(token.RBRACE, p.curToken)
+			return nil
+		}
 		stmt := p.ParseStatement()
 		block.Statements = append(block.Statements, stmt)
+		if p.held {
+			p.held = false
+			continue
+		}
 		p.ReadToken()
 	}
 
@@ -416,40 +1365,86 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 
 
 // Parsing function parameters require validation of the left parantethis
-// as well as the right one. In between, collect an identifier, append it to the list,
-// find the next comma, read next identifier and append it to the list.
+// as well as the right one. In between, collect a parameter, append it to the list,
+// find the next comma, read next parameter and append it to the list.
 // Loop until no comma is found.
-func (p *Parser) parseFunctionParameters() []*ast.IdentifierStatement {
-	identifiers := []*ast.IdentifierStatement{}
+// A parameter is a name, a name with a default value (y = 10) or, in
+// the last place, a variadic name (...rest).
+func (p *Parser) parseFunctionParameters() []*ast.Parameter {
+	open := p.curToken
+	params := []*ast.Parameter{}
 
-	if p.peekToken.Literal == token.RPAREN {
+	for p.peekToken.Type != token.RPAREN {
 		p.ReadToken()
-		return identifiers
+		param := p.parseParameter()
+		if param == nil {
+			return nil
+		}
+		p.checkParameter(param, params)
+		params = append(params, param)
+
+		if p.peekToken.Type != token.COMMA {
+			break
+		}
+		p.ReadToken() //advance the comma char
 	}
 
+	//advance to ')' char. Ready to parse block statements.
+	if p.peekToken.Type != token.RPAREN {
+		p.unclosed(open, token.RPAREN, p.peekToken)
+		return nil
+	}
 	p.ReadToken()
 
-	ident := &ast.IdentifierStatement{
+	return params
+}
+
+func (p *Parser) parseParameter() *ast.Parameter {
+	param := &ast.Parameter{Token: p.curToken}
+
+	if p.curToken.Type == token.ELLIPSIS {
+		param.Variadic = true
+		if !p.expectPeek(token.IDENTIFIER) {
+			return nil
+		}
+	} else if p.curToken.Type != token.IDENTIFIER {
+		p.unexpected(token.IDENTIFIER, p.curToken)
+		return nil
+	}
+	param.Name = &ast.IdentifierStatement{
 		Token: p.curToken,
 		Value: p.curToken.Literal,
 	}
-	identifiers = append(identifiers, ident)
 
-	for p.peekToken.Literal == token.COMMA {
-		p.ReadToken()//advance the comma char
-		p.ReadToken()//advance to the next identifier
-		ident := &ast.IdentifierStatement{
-			Token: p.curToken,
-			Value: p.curToken.Literal,
-		}
-		identifiers = append(identifiers, ident)
+	if p.peekToken.Type == token.ASSIGN {
+		p.ReadToken()
+		p.ReadToken()
+		param.Default = p.parseExpression(ASSIGN)
 	}
 
-	if p.peekToken.Literal != token.RPAREN {
-		return nil
-	}
+	return param
+}
+
+//checkParameter reports a parameter that does not fit after the ones
+//before it. The function is still built, as it is clear what was meant.
+func (p *Parser) checkParameter(param *ast.Parameter, before []*ast.Parameter) {
+	pos := param.Token.Start
+	name := param.Name.Value
 
-	p.ReadToken() //advance to ')' char. Ready to parse block statements.
+	for _, b := range before {
+		if b.Name.Value == name {
+			p.error(InvalidParameter, pos, "duplicate parameter %s", name)
+			return
+		}
+	}
 
-	return identifiers
+	switch {
+	case param.Variadic && param.Default != nil:
+		p.error(InvalidParameter, pos, "variadic parameter %s can not have a default value", name)
+	case len(before) > 0 && before[len(before)-1].Variadic:
+		p.error(InvalidParameter, before[len(before)-1].Token.Start,
+			"variadic parameter %s must be the last one", before[len(before)-1].Name.Value)
+	case !param.Variadic && param.Default == nil && len(before) > 0 && before[len(before)-1].Default != nil:
+		p.error(InvalidParameter, pos, "parameter %s without a default value follows one with a default value", name)
+	}
 }
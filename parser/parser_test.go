@@ -3,7 +3,10 @@ package parser
 import (
 	"interpreter_in_go/ast"
 	"interpreter_in_go/lexer"
+	"interpreter_in_go/token"
+	"strings"
 	"testing"
+	"time"
 
 	is2 "github.com/matryer/is"
 )
@@ -46,6 +49,26 @@ func TestReturnStatements(t *testing.T) {
 	}
 }
 
+func TestBareReturnStatements(t *testing.T) {
+	is := is2.New(t)
+	input := `return; fn() { return }; return`
+	p := NewParser(lexer.NewLexer(input))
+	prog := p.ParseProgram()
+
+	is.Equal(len(p.Errors()), 0)
+	is.Equal(len(prog.Statements), 3)
+	ret, ok := prog.Statements[0].(*ast.ReturnStatement)
+	is.True(ok)
+	is.Equal(ret.ReturnValue, nil)
+	is.Equal(ret.String(), "return ;")
+	fn := prog.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	_, ok = fn.Body.Statements[0].(*ast.ReturnStatement)
+	is.True(ok)
+	ret, ok = prog.Statements[2].(*ast.ReturnStatement)
+	is.True(ok)
+	is.Equal(ret.ReturnValue, nil)
+}
+
 func TestParseLetStatements(t *testing.T) {
 	is := is2.New(t)
 
@@ -67,7 +90,7 @@ func TestParseLetStatements(t *testing.T) {
 	}{
 		{"x"},
 		{"y"},
-		{"foo"},
+		{"bar"},
 	}
 
 	for i, ts := range tests {
@@ -84,8 +107,10 @@ func testLetStatement(is *is2.I, stmt ast.Statement, expectedIdent string) {
 	letStmt, ok := stmt.(*ast.LetStatement)
 	is.Equal(ok, true)
 
-	is.True(letStmt.Name.Value == expectedIdent)
-	is.True(letStmt.Name.TokenLiteral() == expectedIdent)
+	name, ok := letStmt.Name.(*ast.IdentifierStatement)
+	is.True(ok)
+	is.True(name.Value == expectedIdent)
+	is.True(name.TokenLiteral() == expectedIdent)
 }
 
 func TestStringOfStatement(t *testing.T) {
@@ -98,10 +123,258 @@ func TestStringOfStatement(t *testing.T) {
 	p := NewParser(l)
 	prog := p.ParseProgram()
 
-	expected := "let x = 5; return x;"
+	expected := "let x = 5;return x;"
 	is.Equal(expected, prog.String())
 }
 
+func TestParseFromReader(t *testing.T) {
+	is := is2.New(t)
+	input := strings.NewReader(`
+      let x = 5 * y + 2;
+      let name = "Monkey";
+    `)
+	l := lexer.NewReaderLexer(input, lexer.WithBufferSize(4))
+	p := NewParser(l)
+	prog := p.ParseProgram()
+
+	is.Equal(len(prog.Statements), 2)
+	is.Equal(`let x = ((5 * y) + 2);let name = "Monkey";`, prog.String())
+}
+
+func TestParseWithComments(t *testing.T) {
+	is := is2.New(t)
+	input := `// first
+let x = 1; // hi
+/* last */`
+	p := NewParser(lexer.NewLexer(input, lexer.WithComments()))
+	prog := p.ParseProgram()
+
+	is.Equal(len(p.Errors()), 0)
+	is.Equal(len(prog.Statements), 1)
+	testLetStatement(is, prog.Statements[0], "x")
+}
+
+func TestLexerErrorsAreReported(t *testing.T) {
+	is := is2.New(t)
+	input := `let a = "unterminated;
+let b = 0x;`
+	l := lexer.NewLexer(input)
+	p := NewParser(l)
+	p.ParseProgram()
+
+	is.Equal(len(p.Errors()), 2)
+	is.Equal(p.Errors()[0].Error(), "1:9: unterminated string")
+	is.Equal(p.Errors()[1].Error(), "2:9: invalid number: hexadecimal literal has no digits")
+	is.Equal(p.Errors()[1].Code, LexicalError)
+}
+
+func TestParserErrors(t *testing.T) {
+	is := is2.New(t)
+	tests := []struct {
+		input    string
+		expected Error
+	}{
+		{"let = 5;", Error{Code: UnexpectedToken, Msg: `expected IDENTIFIER, got =`, Expected: token.IDENTIFIER, Found: token.ASSIGN}},
+		{"let x 5;", Error{Code: UnexpectedToken, Msg: `expected =, got INT "5"`, Expected: token.ASSIGN, Found: token.INT}},
+		{"let x", Error{Code: UnexpectedToken, Msg: `expected =, got end of input`, Expected: token.ASSIGN, Found: token.EOF}},
+		{"(1 + 2", Error{Code: UnexpectedToken, Msg: `expected ) to close the ( at 1:1, got end of input`, Expected: token.RPAREN, Found: token.EOF}},
+		{"add(1, 2", Error{Code: UnexpectedToken, Msg: `expected ) to close the ( at 1:4, got end of input`, Expected: token.RPAREN, Found: token.EOF}},
+		{"add(1 2)", Error{Code: UnexpectedToken, Msg: `expected ) to close the ( at 1:4, got INT "2"`, Expected: token.RPAREN, Found: token.INT}},
+		{"add(1, , 2)", Error{Code: NoPrefixParseFn, Msg: `no prefix parse function for ,`}},
+		{"add(1))", Error{Code: NoPrefixParseFn, Msg: `no prefix parse function for )`}},
+		{"[1, 2", Error{Code: UnexpectedToken, Msg: `expected ] to close the [ at 1:1, got end of input`, Expected: token.RBRACKET, Found: token.EOF}},
+		{"xs[1", Error{Code: UnexpectedToken, Msg: `expected ] to close the [ at 1:3, got end of input`, Expected: token.RBRACKET, Found: token.EOF}},
+		{"xs[1:2:3]", Error{Code: UnexpectedToken, Msg: `expected ] to close the [ at 1:3, got :`, Expected: token.RBRACKET, Found: token.COLON}},
+		{"xs[]", Error{Code: NoPrefixParseFn, Msg: `no prefix parse function for ]`}},
+		{`let h = {"a" 1};`, Error{Code: UnexpectedToken, Msg: `expected :, got INT "1"`, Expected: token.COLON, Found: token.INT}},
+		{`let h = {"a": 1 "b": 2};`, Error{Code: UnexpectedToken, Msg: `expected } to close the { at 1:9, got STRING`, Expected: token.RBRACE, Found: token.STRING}},
+		{"user.", Error{Code: UnexpectedToken, Msg: `expected IDENTIFIER, got end of input`, Expected: token.IDENTIFIER, Found: token.EOF}},
+		{"1 = 2", Error{Code: NotAssignable, Msg: `cannot assign to 1`}},
+		{"a + b = c", Error{Code: NotAssignable, Msg: `cannot assign to (a + b)`}},
+		{"f() += 1", Error{Code: NotAssignable, Msg: `cannot assign to f()`}},
+		{"xs[1:2] = ys", Error{Code: NotAssignable, Msg: `cannot assign to (xs[1:2])`}},
+		{"5++", Error{Code: NotAssignable, Msg: `cannot assign to 5`}},
+		{"(a + b)--", Error{Code: NotAssignable, Msg: `cannot assign to (a + b)`}},
+		{"++f()", Error{Code: NotAssignable, Msg: `cannot assign to f()`}},
+		{"a ? b", Error{Code: UnexpectedToken, Msg: `expected :, got end of input`, Expected: token.COLON, Found: token.EOF}},
+		{"a ?? ", Error{Code: NoPrefixParseFn, Msg: `no prefix parse function for end of input`}},
+		{"fn(x, y = 1, z) { }", Error{Code: InvalidParameter, Msg: `parameter z without a default value follows one with a default value`}},
+		{"fn(...rest, x) { }", Error{Code: InvalidParameter, Msg: `variadic parameter rest must be the last one`}},
+		{"fn(...rest = []) { }", Error{Code: InvalidParameter, Msg: `variadic parameter rest can not have a default value`}},
+		{"fn(x, x) { }", Error{Code: InvalidParameter, Msg: `duplicate parameter x`}},
+		{"fn(...) { }", Error{Code: UnexpectedToken, Msg: `expected IDENTIFIER, got )`, Expected: token.IDENTIFIER, Found: token.RPAREN}},
+		{"[...xs]", Error{Code: NoPrefixParseFn, Msg: `no prefix parse function for ...`}},
+		{"let [a, ...rest, b] = xs;", Error{Code: InvalidPattern, Msg: `rest element ...rest must be the last one`}},
+		{"let [...rest = []] = xs;", Error{Code: InvalidPattern, Msg: `rest element ...rest can not have a default value`}},
+		{"let [a, {b: a}] = xs;", Error{Code: InvalidPattern, Msg: `a is bound more than once`}},
+		{"let [a, 1] = xs;", Error{Code: UnexpectedToken, Msg: `expected IDENTIFIER, got INT "1"`, Expected: token.IDENTIFIER, Found: token.INT}},
+		{"let {a: 1} = h;", Error{Code: UnexpectedToken, Msg: `expected IDENTIFIER, got INT "1"`, Expected: token.IDENTIFIER, Found: token.INT}},
+		{`let {"a"} = h;`, Error{Code: UnexpectedToken, Msg: `expected :, got }`, Expected: token.COLON, Found: token.RBRACE}},
+		{"let {...rest} = h;", Error{Code: UnexpectedToken, Msg: `expected IDENTIFIER, got ...`, Expected: token.IDENTIFIER, Found: token.ELLIPSIS}},
+		{"let [a, b = xs;", Error{Code: UnexpectedToken, Msg: `expected ] to close the [ at 1:5, got ;`, Expected: token.RBRACKET, Found: token.SEMICOLON}},
+		{"while x { }", Error{Code: UnexpectedToken, Msg: `expected (, got IDENTIFIER "x"`, Expected: token.LPAREN, Found: token.IDENTIFIER}},
+		{"for (let i = 0 i < 3; i++) { }", Error{Code: UnexpectedToken, Msg: `expected ;, got IDENTIFIER "i"`, Expected: token.SEMICOLON, Found: token.IDENTIFIER}},
+		{"for (i; i < 3) { }", Error{Code: UnexpectedToken, Msg: `expected ;, got )`, Expected: token.SEMICOLON, Found: token.RPAREN}},
+		{"for (k, 1 in h) { }", Error{Code: UnexpectedToken, Msg: `expected IDENTIFIER, got INT "1"`, Expected: token.IDENTIFIER, Found: token.INT}},
+		{"for (k, v of h) { }", Error{Code: UnexpectedToken, Msg: `expected IN, got IDENTIFIER "of"`, Expected: token.IN, Found: token.IDENTIFIER}},
+		{"break;", Error{Code: OutsideLoop, Msg: `break outside of a loop`}},
+		{"while (x) { fn() { continue; } }", Error{Code: OutsideLoop, Msg: `continue outside of a loop`}},
+		{`{ let x = 1;`, Error{Code: UnexpectedToken, Msg: `expected }, got end of input`, Expected: token.RBRACE, Found: token.EOF}},
+		{"if x { 1 }", Error{Code: UnexpectedToken, Msg: `expected (, got IDENTIFIER "x"`, Expected: token.LPAREN, Found: token.IDENTIFIER}},
+		{"if (x) { 1 } else 2", Error{Code: UnexpectedToken, Msg: `expected {, got INT "2"`, Expected: token.LBRACE, Found: token.INT}},
+		{"if (x) { 1 } else if { 2 }", Error{Code: UnexpectedToken, Msg: `expected (, got {`, Expected: token.LPAREN, Found: token.LBRACE}},
+		{"if (x) 1", Error{Code: UnexpectedToken, Msg: `expected {, got INT "1"`, Expected: token.LBRACE, Found: token.INT}},
+		{"fn x { 1 }", Error{Code: UnexpectedToken, Msg: `expected (, got IDENTIFIER "x"`, Expected: token.LPAREN, Found: token.IDENTIFIER}},
+		{"fn(x, 1) { x }", Error{Code: UnexpectedToken, Msg: `expected IDENTIFIER, got INT "1"`, Expected: token.IDENTIFIER, Found: token.INT}},
+		{"fn(x y) { x }", Error{Code: UnexpectedToken, Msg: `expected ) to close the ( at 1:3, got IDENTIFIER "y"`, Expected: token.RPAREN, Found: token.IDENTIFIER}},
+		{"fn(x) x", Error{Code: UnexpectedToken, Msg: `expected {, got IDENTIFIER "x"`, Expected: token.LBRACE, Found: token.IDENTIFIER}},
+		{"* 5", Error{Code: NoPrefixParseFn, Msg: `no prefix parse function for *`}},
+		{"99999999999999999999", Error{Code: InvalidLiteral, Msg: `could not parse "99999999999999999999" as integer`}},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(lexer.NewLexer(tt.input))
+		p.ParseProgram()
+
+		is.True(len(p.Errors()) > 0) // an error for each rejected program
+		err := p.Errors()[0]
+		is.Equal(err.Severity, SeverityError)
+		is.Equal(err.Code, tt.expected.Code)
+		is.Equal(err.Msg, tt.expected.Msg)
+		is.Equal(err.Expected, tt.expected.Expected)
+		is.Equal(err.Found, tt.expected.Found)
+	}
+}
+
+func TestParserErrorPositions(t *testing.T) {
+	is := is2.New(t)
+	p := NewParser(lexer.NewLexer("let x = 1;\nlet y 2;", lexer.WithFilename("main.mk")))
+	p.ParseProgram()
+
+	is.True(len(p.Errors()) > 0)
+	is.Equal(p.Errors()[0].Error(), `main.mk:2:7: expected =, got INT "2"`)
+	is.Equal(p.Errors()[0].Code.String(), "unexpected-token")
+}
+
+func TestErrorRecovery(t *testing.T) {
+	is := is2.New(t)
+	input := `let x 5;
+let y = 10;
+let = 3;
+return y;
+fn(a) { let b 1; b };
+)`
+	p := NewParser(lexer.NewLexer(input))
+	prog := p.ParseProgram()
+
+	msgs := []string{}
+	for _, err := range p.Errors() {
+		msgs = append(msgs, err.Error())
+	}
+	is.Equal(msgs, []string{
+		`1:7: expected =, got INT "5"`,
+		`3:5: expected IDENTIFIER, got =`,
+		`5:15: expected =, got INT "1"`,
+		`6:1: no prefix parse function for )`,
+	})
+
+	is.Equal(len(prog.Statements), 6)
+	_, ok := prog.Statements[0].(*ast.BadStatement)
+	is.True(ok)
+	testLetStatement(is, prog.Statements[1], "y")
+	_, ok = prog.Statements[2].(*ast.BadStatement)
+	is.True(ok)
+	_, ok = prog.Statements[3].(*ast.ReturnStatement)
+	is.True(ok)
+
+	//the error inside the function body does not spoil the function
+	exp, ok := prog.Statements[4].(*ast.ExpressionStatement)
+	is.True(ok)
+	fn, ok := exp.Expression.(*ast.FunctionLiteral)
+	is.True(ok)
+	is.Equal(len(fn.Body.Statements), 2)
+	bad, ok := fn.Body.Statements[0].(*ast.BadStatement)
+	is.True(ok)
+	is.Equal(bad.Token.Start.String(), "5:9")
+	is.Equal(fn.Body.Statements[1].String(), "b")
+
+	//an error found on the '}' that closes a block leaves it to the block
+	tests := []struct {
+		input string
+		err   string
+		last  string
+	}{
+		{"fn() { x * }; let y = 2;", "1:12: no prefix parse function for }", "let y = 2;"},
+		{"while (a) { x + }; let y = 2;", "1:17: no prefix parse function for }", "let y = 2;"},
+		{"fn() { let x = }; let y = 2;", "1:16: no prefix parse function for }", "let y = 2;"},
+		{"if (a) { let x = } else { 1 }", "1:18: no prefix parse function for }", "ifa <bad statement> else 1"},
+		{"{ let h = {a: }; x }", "1:15: no prefix parse function for }", "<bad statement>x"},
+	}
+
+	//a block opened by the statement at fault is skipped whole
+	for _, input := range []string{"if (a { 1 }; let q = 2;", "for (x in) { }"} {
+		p := NewParser(lexer.NewLexer(input))
+		p.ParseProgram()
+
+		is.Equal(len(p.Errors()), 1)
+	}
+
+	for _, tt := range tests {
+		p := NewParser(lexer.NewLexer(tt.input))
+		prog := p.ParseProgram()
+
+		is.Equal(len(p.Errors()), 1)
+		is.Equal(p.Errors()[0].Error(), tt.err)
+		is.Equal(prog.Statements[len(prog.Statements)-1].String(), tt.last)
+	}
+}
+
+func TestErrorRecoveryTerminates(t *testing.T) {
+	is := is2.New(t)
+	tests := []string{
+		"let x",
+		"let x 5",
+		"let",
+		"return +",
+		"fn(x) { x",
+		"fn(x) { let y = ",
+		"fn(x { x }",
+		"if (x) { y",
+		"}}}",
+		"(((",
+		"let a = 1; } let b = 2;",
+	}
+
+	for _, input := range tests {
+		done := make(chan []Error)
+		go func() {
+			p := NewParser(lexer.NewLexer(input))
+			p.ParseProgram()
+			done <- p.Errors()
+		}()
+
+		select {
+		case errs := <-done:
+			is.True(len(errs) > 0) // every input above has an error
+		case <-time.After(time.Second):
+			t.Fatalf("parsing %q does not terminate", input)
+		}
+	}
+}
+
+func TestBadExpression(t *testing.T) {
+	is := is2.New(t)
+	p := NewParser(lexer.NewLexer(`let a = "unterminated;`))
+	prog := p.ParseProgram()
+
+	is.Equal(len(p.Errors()), 1)
+	is.Equal(p.Errors()[0].Code, LexicalError)
+	let, ok := prog.Statements[0].(*ast.LetStatement)
+	is.True(ok)
+	_, ok = let.Value.(*ast.BadExpression)
+	is.True(ok)
+}
+
 func TestParseExpression(t *testing.T) {
 	is := is2.New(t)
 	input := `
@@ -142,6 +415,53 @@ func TestParseIntegerLiteral(t *testing.T) {
 	is.Equal(int64(5), intLiteral.Value)
 }
 
+func TestParseNumberLiterals(t *testing.T) {
+	is := is2.New(t)
+	intTests := []struct {
+		input    string
+		expected int64
+	}{
+		{"0xFF", 255},
+		{"0o755", 493},
+		{"0b1010", 10},
+		{"1_000_000", 1000000},
+	}
+
+	for _, tt := range intTests {
+		prog := NewParser(lexer.NewLexer(tt.input)).ParseProgram()
+
+		is.Equal(len(prog.Statements), 1)
+		exp, ok := prog.Statements[0].(*ast.ExpressionStatement)
+		is.True(ok)
+		intLiteral, ok := exp.Expression.(*ast.IntegerLiteral)
+		is.True(ok)
+		is.Equal(tt.expected, intLiteral.Value)
+		is.Equal(tt.input, intLiteral.String())
+	}
+
+	floatTests := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.14", 3.14},
+		{"1e-9", 1e-9},
+		{"2.5E+3", 2500},
+		{"1_000.5", 1000.5},
+	}
+
+	for _, tt := range floatTests {
+		prog := NewParser(lexer.NewLexer(tt.input)).ParseProgram()
+
+		is.Equal(len(prog.Statements), 1)
+		exp, ok := prog.Statements[0].(*ast.ExpressionStatement)
+		is.True(ok)
+		floatLiteral, ok := exp.Expression.(*ast.FloatLiteral)
+		is.True(ok)
+		is.Equal(tt.expected, floatLiteral.Value)
+		is.Equal(tt.input, floatLiteral.String())
+	}
+}
+
 func TestParsingPrefixExpressions(t *testing.T) {
 	is := is2.New(t)
 	prefixTests := []struct {
@@ -186,6 +506,9 @@ func TestParsingInfixExpressions(t *testing.T) {
 		{"5 < 5;", 5, "<", 5},
 		{"5 == 5;", 5, "==", 5},
 		{"5 != 5;", 5, "!=", 5},
+		{"5 % 5;", 5, "%", 5},
+		{"5 <= 5;", 5, "<=", 5},
+		{"5 >= 5;", 5, ">=", 5},
 	}
 
 	for _, tt := range infixTests {
@@ -208,6 +531,109 @@ func TestParsingInfixExpressions(t *testing.T) {
 	}
 }
 
+func TestStringLiteralParsing(t *testing.T) {
+	is := is2.New(t)
+	input := `let greeting = "hello \"world\"\n";`
+	l := lexer.NewLexer(input)
+	p := NewParser(l)
+	prog := p.ParseProgram()
+
+	is.Equal(len(prog.Statements), 1)
+
+	letStmt, ok := prog.Statements[0].(*ast.LetStatement)
+	is.True(ok)
+	str, ok := letStmt.Value.(*ast.StringLiteral)
+	is.True(ok)
+	is.Equal(str.Value, "hello \"world\"\n")
+	is.Equal(prog.String(), `let greeting = "hello \"world\"\n";`)
+}
+
+func TestTemplateLiteralParsing(t *testing.T) {
+	is := is2.New(t)
+	input := `"Hello ${user}, you have ${count + 1} items";`
+	l := lexer.NewLexer(input)
+	p := NewParser(l)
+	prog := p.ParseProgram()
+
+	is.Equal(len(p.errors), 0)
+	is.Equal(len(prog.Statements), 1)
+	exp, ok := prog.Statements[0].(*ast.ExpressionStatement)
+	is.True(ok)
+	tl, ok := exp.Expression.(*ast.TemplateLiteral)
+	is.True(ok)
+	is.Equal(tl.Texts, []string{"Hello ", ", you have ", " items"})
+	is.Equal(len(tl.Expressions), 2)
+	is.Equal(tl.Expressions[0].String(), "user")
+	is.Equal(tl.Expressions[1].String(), "(count + 1)")
+	is.Equal(tl.String(), `"Hello ${user}, you have ${(count + 1)} items"`)
+}
+
+func TestTemplateLiteralRoundTrip(t *testing.T) {
+	is := is2.New(t)
+	tests := []string{
+		`"${a}${b}"`,
+		`"outer ${"inner ${x * 2} \${not}"} done"`,
+		`"price: \${5} or ${price}$"`,
+	}
+
+	for _, input := range tests {
+		p := NewParser(lexer.NewLexer(input))
+		prog := p.ParseProgram()
+		is.Equal(len(p.errors), 0)
+
+		again := NewParser(lexer.NewLexer(prog.String())).ParseProgram()
+		is.Equal(prog.String(), again.String())
+	}
+}
+
+func TestTemplateLiteralErrors(t *testing.T) {
+	is := is2.New(t)
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"a ${} b"`, "1:6: empty expression in string interpolation"},
+		{`"a ${x y} b"`, "1:8: expected } to close the string interpolation, got IDENTIFIER"},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(lexer.NewLexer(tt.input))
+		p.ParseProgram()
+
+		is.True(len(p.errors) > 0)
+		is.Equal(p.errors[0].Error(), tt.expected)
+	}
+}
+
+func TestRawStringLiteralParsing(t *testing.T) {
+	is := is2.New(t)
+	tests := []struct {
+		input         string
+		expectedValue string
+	}{
+		{"`C:\\new\\${dir}`", "C:\\new\\${dir}"},
+		{"`one\n  two`", "one\n  two"},
+		{"`\n    SELECT *\n    FROM users\n    `", "\n    SELECT *\n    FROM users\n    "},
+		{"```\n    SELECT *\n      FROM users\n\n    WHERE id = 1\n    ```", "SELECT *\n  FROM users\n\nWHERE id = 1\n"},
+		{"```\r\n\tdone\r\n```", "done\r\n"},
+		{"```  a `b` c```", "  a `b` c"},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(lexer.NewLexer(tt.input))
+		prog := p.ParseProgram()
+		is.Equal(len(p.errors), 0)
+
+		exp, ok := prog.Statements[0].(*ast.ExpressionStatement)
+		is.True(ok)
+		str, ok := exp.Expression.(*ast.StringLiteral)
+		is.True(ok)
+		is.True(str.Raw)
+		is.Equal(str.Value, tt.expectedValue)
+		is.Equal(str.String(), tt.input)
+	}
+}
+
 func TestBooleanLiteralParsing(t *testing.T){
 	is := is2.New(t)
 	input := `let isMonday = false`
@@ -219,10 +645,33 @@ func TestBooleanLiteralParsing(t *testing.T){
 	
 	letStmt, ok := prog.Statements[0].(*ast.LetStatement)
 	is.True(ok)
-	is.Equal(letStmt.Name.Value, "isMonday")
+	is.Equal(letStmt.Name.String(), "isMonday")
 	is.Equal(letStmt.Value.String(), "false")
 }
 
+func TestBooleanLiteralWithKeywords(t *testing.T) {
+	is := is2.New(t)
+	kw := token.DefaultKeywords()
+	kw["seja"] = token.LET
+	kw["verdadeiro"] = token.TRUE
+	kw["falso"] = token.FALSE
+	p := NewParser(lexer.NewLexer("seja x = verdadeiro; falso", lexer.WithKeywords(kw)))
+	prog := p.ParseProgram()
+
+	is.Equal(len(p.Errors()), 0)
+	is.Equal(len(prog.Statements), 2)
+	let, ok := prog.Statements[0].(*ast.LetStatement)
+	is.True(ok)
+	b, ok := let.Value.(*ast.BooleanLiteral)
+	is.True(ok)
+	is.True(b.Value)
+	exp, ok := prog.Statements[1].(*ast.ExpressionStatement)
+	is.True(ok)
+	b, ok = exp.Expression.(*ast.BooleanLiteral)
+	is.True(ok)
+	is.True(!b.Value)
+}
+
 func TestInfixBooleanExpressions(t *testing.T){
 	is := is2.New(t)
 	boolExps := []struct{
@@ -310,6 +759,126 @@ func  TestOperatorPrecedenceParsing(t * testing.T){
 			"!(true == true)",
 			"(!(true == true))",
 		},
+		{
+			"a <= b && c != d",
+			"((a <= b) && (c != d))",
+		},
+		{
+			"a || b && c >= d % 2",
+			"(a || (b && (c >= (d % 2))))",
+		},
+		{
+			"a && b && c || d || e",
+			"((((a && b) && c) || d) || e)",
+		},
+		{
+			"!a && b == c || -d < e",
+			"(((!a) && (b == c)) || ((-d) < e))",
+		},
+		{
+			`age >= 18 && (country == "PT" || vip)`,
+			`((age >= 18) && ((country == "PT") || vip))`,
+		},
+		{
+			"a ? b : c ? d : e",
+			"(a ? b : (c ? d : e))",
+		},
+		{
+			"a ? b ? c : d : e",
+			"(a ? (b ? c : d) : e)",
+		},
+		{
+			"x = a || b ? c + 1 : d ?? e",
+			"(x = ((a || b) ? (c + 1) : (d ?? e)))",
+		},
+		{
+			"a ?? b ?? c",
+			"(a ?? (b ?? c))",
+		},
+		{
+			"xs |> filter(isEven) |> map(double)",
+			"((xs |> filter(isEven)) |> map(double))",
+		},
+		{
+			"total = xs |> sum ?? 0",
+			"(total = (xs |> (sum ?? 0)))",
+		},
+		{
+			"a + b |> f(c * d)",
+			"((a + b) |> f((c * d)))",
+		},
+		{
+			"ok ? xs |> f : ys |> g",
+			"((ok ? (xs |> f) : ys) |> g)",
+		},
+		{
+			"a ?? b || c ? d : e",
+			"((a ?? (b || c)) ? d : e)",
+		},
+		{
+			"f(a ? b : c, d ?? 0)[n > 0 ? 1 : 0]",
+			"(f((a ? b : c), (d ?? 0))[((n > 0) ? 1 : 0)])",
+		},
+		{
+			"i++",
+			"(i++)",
+		},
+		{
+			"-i-- * 2",
+			"((-(i--)) * 2)",
+		},
+		{
+			"++i + --j",
+			"((++i) + (--j))",
+		},
+		{
+			"x += 2",
+			"(x += 2)",
+		},
+		{
+			"x += y *= 2 + 1",
+			"(x += (y *= (2 + 1)))",
+		},
+		{
+			"x -= a || b; x /= 3",
+			"(x -= (a || b))(x /= 3)",
+		},
+		{
+			"a + add(b * c) + d",
+			"((a + add((b * c))) + d)",
+		},
+		{
+			"add(a, b, 1, 2 * 3, 4 + 5, add(6, 7 * 8))",
+			"add(a, b, 1, (2 * 3), (4 + 5), add(6, (7 * 8)))",
+		},
+		{
+			"-f(x)++",
+			"(-(f(x)++))",
+		},
+		{
+			"a * b(c)(d)",
+			"(a * b(c)(d))",
+		},
+		{
+			"a * [1, 2, 3, 4][b * c] * d",
+			"((a * ([1, 2, 3, 4][(b * c)])) * d)",
+		},
+		{
+			"add(a * b[2], b[1], 2 * [1, 2][1])",
+			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
+		},
+		{
+			"getList()[0][1]",
+			"((getList()[0])[1])",
+		},
+		{
+			"-xs[-1]",
+			"(-(xs[(-1)]))",
+		},
+		{
+			"xs[i++]++",
+			"((xs[(i++)])++)",
+		},
 	}
 
 	for _, t := range exps {
@@ -355,4 +924,572 @@ func TestFunctionLiteralParsing(t *testing.T){
 
 	is.Equal(len(function.Parameters), 2)
 	is.Equal(len(function.Body.Statements), 1)
-}
\ No newline at end of file
+}
+
+func TestCallExpressionParsing(t *testing.T) {
+	is := is2.New(t)
+	tests := []struct {
+		input        string
+		function     string
+		expectedArgs []string
+	}{
+		{"add(1, 2 * 3, 4 + 5);", "add", []string{"1", "(2 * 3)", "(4 + 5)"}},
+		{"add();", "add", []string{}},
+		{"add(1, 2,);", "add", []string{"1", "2"}},
+		{"add(\n  x,\n  y,\n);", "add", []string{"x", "y"}},
+		{"curry(1)(2);", "curry(1)", []string{"2"}},
+		{"fn(x) { x }(5);", "fn(x)x", []string{"5"}},
+		{"(fn(x) { x })(5);", "fn(x)x", []string{"5"}},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(lexer.NewLexer(tt.input))
+		prog := p.ParseProgram()
+		is.Equal(len(p.Errors()), 0)
+		is.Equal(len(prog.Statements), 1)
+
+		stmt, ok := prog.Statements[0].(*ast.ExpressionStatement)
+		is.True(ok)
+		call, ok := stmt.Expression.(*ast.CallExpression)
+		is.True(ok)
+		is.Equal(call.Function.String(), tt.function)
+
+		args := []string{}
+		for _, a := range call.Arguments {
+			args = append(args, a.String())
+		}
+		is.Equal(args, tt.expectedArgs)
+	}
+}
+
+func TestArrayLiteralParsing(t *testing.T) {
+	is := is2.New(t)
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"[1, 2 * 2, 3 + 3]", []string{"1", "(2 * 2)", "(3 + 3)"}},
+		{"[]", []string{}},
+		{"[\"a\", [b], fn(x) { x },]", []string{`"a"`, "[b]", "fn(x)x"}},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(lexer.NewLexer(tt.input))
+		prog := p.ParseProgram()
+		is.Equal(len(p.Errors()), 0)
+
+		stmt, ok := prog.Statements[0].(*ast.ExpressionStatement)
+		is.True(ok)
+		array, ok := stmt.Expression.(*ast.ArrayLiteral)
+		is.True(ok)
+
+		elements := []string{}
+		for _, e := range array.Elements {
+			elements = append(elements, e.String())
+		}
+		is.Equal(elements, tt.expected)
+	}
+}
+
+func TestIndexAndSliceParsing(t *testing.T) {
+	is := is2.New(t)
+	tests := []struct {
+		input string
+		low   string
+		high  string
+		slice bool
+	}{
+		{"xs[1 + 1]", "(1 + 1)", "", false},
+		{"xs[-1]", "(-1)", "", false},
+		{"xs[1:3]", "1", "3", true},
+		{"xs[:2]", "", "2", true},
+		{"xs[n:]", "n", "", true},
+		{"xs[:]", "", "", true},
+	}
+
+	str := func(e ast.Expression) string {
+		if e == nil {
+			return ""
+		}
+		return e.String()
+	}
+
+	for _, tt := range tests {
+		p := NewParser(lexer.NewLexer(tt.input))
+		prog := p.ParseProgram()
+		is.Equal(len(p.Errors()), 0)
+
+		stmt, ok := prog.Statements[0].(*ast.ExpressionStatement)
+		is.True(ok)
+		if !tt.slice {
+			index, ok := stmt.Expression.(*ast.IndexExpression)
+			is.True(ok)
+			is.Equal(index.Left.String(), "xs")
+			is.Equal(index.Index.String(), tt.low)
+			continue
+		}
+		slice, ok := stmt.Expression.(*ast.SliceExpression)
+		is.True(ok)
+		is.Equal(slice.Left.String(), "xs")
+		is.Equal(str(slice.Low), tt.low)
+		is.Equal(str(slice.High), tt.high)
+		is.Equal(slice.String(), "("+tt.input+")")
+	}
+}
+
+func TestHashLiteralParsing(t *testing.T) {
+	is := is2.New(t)
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let h = {"name": "Thorsten", "age": 28};`, `{"name": "Thorsten", "age": 28}`},
+		{`let h = {};`, `{}`},
+		{`let h = {1: 1 + 1, true: f(x), "k" + "ey": [1],};`, `{1: (1 + 1), true: f(x), ("k" + "ey"): [1]}`},
+		{`let h = {"a": {"b": {}}, "c": {"d": 1,},};`, `{"a": {"b": {}}, "c": {"d": 1}}`},
+		{`let v = {"a": 1}["a"];`, `({"a": 1}["a"])`},
+		{`({"a": 1});`, `{"a": 1}`},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(lexer.NewLexer(tt.input))
+		prog := p.ParseProgram()
+		is.Equal(len(p.Errors()), 0)
+		is.Equal(len(prog.Statements), 1)
+
+		var exp ast.Expression
+		switch stmt := prog.Statements[0].(type) {
+		case *ast.LetStatement:
+			exp = stmt.Value
+		case *ast.ExpressionStatement:
+			exp = stmt.Expression
+		}
+		is.Equal(exp.String(), tt.expected)
+	}
+}
+
+func TestHashPairsKeepTheirOrder(t *testing.T) {
+	is := is2.New(t)
+	p := NewParser(lexer.NewLexer(`let h = {"z": 1, "a": 2, "m": 3};`))
+	prog := p.ParseProgram()
+	is.Equal(len(p.Errors()), 0)
+
+	let := prog.Statements[0].(*ast.LetStatement)
+	hash, ok := let.Value.(*ast.HashLiteral)
+	is.True(ok)
+
+	keys := []string{}
+	for _, pair := range hash.Pairs {
+		keys = append(keys, pair.Key.(*ast.StringLiteral).Value)
+	}
+	is.Equal(keys, []string{"z", "a", "m"})
+}
+
+func TestBlockInStatementPosition(t *testing.T) {
+	is := is2.New(t)
+	p := NewParser(lexer.NewLexer(`{ let h = {"a": 1}; h }`))
+	prog := p.ParseProgram()
+	is.Equal(len(p.Errors()), 0)
+	is.Equal(len(prog.Statements), 1)
+
+	block, ok := prog.Statements[0].(*ast.BlockStatement)
+	is.True(ok)
+	is.Equal(len(block.Statements), 2)
+	testLetStatement(is, block.Statements[0], "h")
+	is.Equal(block.Statements[1].String(), "h")
+}
+
+func TestAssignExpressionParsing(t *testing.T) {
+	is := is2.New(t)
+	tests := []struct {
+		input    string
+		target   string
+		operator string
+		value    string
+	}{
+		{"x = 5;", "x", "=", "5"},
+		{"x += 1;", "x", "+=", "1"},
+		{"x = y = 5;", "x", "=", "(y = 5)"},
+		{"x = a || b;", "x", "=", "(a || b)"},
+		{"arr[0] = 2 * 3;", "(arr[0])", "=", "(2 * 3)"},
+		{`h["k"] -= v;`, `(h["k"])`, "-=", "v"},
+		{`user.name = "Pete";`, "(user.name)", "=", `"Pete"`},
+		{"a.b[0].c *= 2;", "(((a.b)[0]).c)", "*=", "2"},
+		{"total /= count(xs);", "total", "/=", "count(xs)"},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(lexer.NewLexer(tt.input))
+		prog := p.ParseProgram()
+		is.Equal(len(p.Errors()), 0)
+		is.Equal(len(prog.Statements), 1)
+
+		stmt, ok := prog.Statements[0].(*ast.ExpressionStatement)
+		is.True(ok)
+		assign, ok := stmt.Expression.(*ast.AssignExpression)
+		is.True(ok)
+		is.Equal(assign.Target.String(), tt.target)
+		is.Equal(assign.Operator, tt.operator)
+		is.Equal(assign.Value.String(), tt.value)
+	}
+}
+
+func TestNotAssignableKeepsTheTree(t *testing.T) {
+	is := is2.New(t)
+	p := NewParser(lexer.NewLexer("1 = 2; x = 3;"))
+	prog := p.ParseProgram()
+
+	is.Equal(len(p.Errors()), 1)
+	is.Equal(p.Errors()[0].Error(), "1:3: cannot assign to 1")
+	is.Equal(prog.String(), "(1 = 2)(x = 3)")
+}
+
+func TestLoopParsing(t *testing.T) {
+	is := is2.New(t)
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"while (x < 10) { x += 1; }", "while ((x < 10)) (x += 1)"},
+		{"for (let i = 0; i < n; i++) { sum += i; }", "for (let i = 0; (i < n); (i++)) (sum += i)"},
+		{"for (i = 0; i < n; i += 2) { }", "for ((i = 0); (i < n); (i += 2)) "},
+		{"for (;;) { break; }", "for (;;) break;"},
+		{"for (; x;) { continue }", "for (; x;) continue;"},
+		{"for (x in xs) { print(x); }", "for (x in xs) print(x)"},
+		{"for (k, v in {\"a\": 1}) { }", "for (k, v in {\"a\": 1}) "},
+		{"while (true) { for (x in xs) { break; } continue; }", "while (true) for (x in xs) break;continue;"},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(lexer.NewLexer(tt.input))
+		prog := p.ParseProgram()
+		is.Equal(len(p.Errors()), 0)
+		is.Equal(len(prog.Statements), 1)
+		is.Equal(prog.String(), tt.expected)
+	}
+}
+
+func TestForStatementParts(t *testing.T) {
+	is := is2.New(t)
+	p := NewParser(lexer.NewLexer("for (let i = 0; i < 3; i++) { i }"))
+	prog := p.ParseProgram()
+	is.Equal(len(p.Errors()), 0)
+
+	loop, ok := prog.Statements[0].(*ast.ForStatement)
+	is.True(ok)
+	testLetStatement(is, loop.Init, "i")
+	is.Equal(loop.Condition.String(), "(i < 3)")
+	is.Equal(loop.Post.String(), "(i++)")
+	is.Equal(len(loop.Body.Statements), 1)
+
+	p = NewParser(lexer.NewLexer("for (k, v in h) { v }"))
+	prog = p.ParseProgram()
+	is.Equal(len(p.Errors()), 0)
+
+	forIn, ok := prog.Statements[0].(*ast.ForInStatement)
+	is.True(ok)
+	is.Equal(forIn.Key.Value, "k")
+	is.Equal(forIn.Value.Value, "v")
+	is.Equal(forIn.Iterable.String(), "h")
+
+	p = NewParser(lexer.NewLexer("for (x in xs) { x }"))
+	prog = p.ParseProgram()
+	forIn = prog.Statements[0].(*ast.ForInStatement)
+	is.True(forIn.Key == nil)
+	is.Equal(forIn.Value.Value, "x")
+}
+
+func TestJumpsInsideLoops(t *testing.T) {
+	is := is2.New(t)
+	input := `
+while (a) {
+  if (b) { break; }
+  let f = fn() { while (c) { continue; } };
+  continue;
+}
+let g = fn() { break; };
+for (x in xs) { }
+continue;`
+	p := NewParser(lexer.NewLexer(input))
+	p.ParseProgram()
+
+	msgs := []string{}
+	for _, err := range p.Errors() {
+		is.Equal(err.Code, OutsideLoop)
+		msgs = append(msgs, err.Error())
+	}
+	is.Equal(msgs, []string{
+		"7:16: break outside of a loop",
+		"9:1: continue outside of a loop",
+	})
+}
+
+func TestIfElseParsing(t *testing.T) {
+	is := is2.New(t)
+	input := `if (x < y) { x } else { y }`
+	p := NewParser(lexer.NewLexer(input))
+	prog := p.ParseProgram()
+	is.Equal(len(p.Errors()), 0)
+	is.Equal(len(prog.Statements), 1)
+
+	exp := prog.Statements[0].(*ast.ExpressionStatement)
+	ifExp, ok := exp.Expression.(*ast.IfExpression)
+	is.True(ok)
+	is.Equal(ifExp.Consequence.String(), "x")
+	is.Equal(ifExp.Alternative.String(), "y")
+	is.Equal(ifExp.String(), "if(x < y) x else y")
+}
+
+func TestIfWithoutElse(t *testing.T) {
+	is := is2.New(t)
+	p := NewParser(lexer.NewLexer(`if (x) { y }; let z = 1;`))
+	prog := p.ParseProgram()
+	is.Equal(len(p.Errors()), 0)
+	is.Equal(len(prog.Statements), 2)
+
+	ifExp := prog.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.IfExpression)
+	is.True(ifExp.Alternative == nil)
+	testLetStatement(is, prog.Statements[1], "z")
+}
+
+func TestElseIfChains(t *testing.T) {
+	is := is2.New(t)
+	input := `let grade = if (n >= 90) { "A" } else if (n >= 80) { "B" } else if (n >= 70) { "C" } else { "F" };`
+	p := NewParser(lexer.NewLexer(input))
+	prog := p.ParseProgram()
+	is.Equal(len(p.Errors()), 0)
+	is.Equal(len(prog.Statements), 1)
+	is.Equal(prog.String(), `let grade = if(n >= 90) "A" else if(n >= 80) "B" else if(n >= 70) "C" else "F";`)
+
+	//walk the chain down to the final else
+	let := prog.Statements[0].(*ast.LetStatement)
+	ifExp := let.Value.(*ast.IfExpression)
+	conditions := []string{}
+	for {
+		conditions = append(conditions, ifExp.Condition.String())
+		alt := ifExp.Alternative
+		next, ok := alt.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.IfExpression)
+		if !ok || alt.Token.Type != token.IF {
+			is.Equal(alt.String(), `"F"`)
+			break
+		}
+		ifExp = next
+	}
+	is.Equal(conditions, []string{"(n >= 90)", "(n >= 80)", "(n >= 70)"})
+}
+
+func TestLongElseIfChain(t *testing.T) {
+	is := is2.New(t)
+	input := "if (x == 0) { 0 }" + strings.Repeat(" else if (x == 1) { 1 }", 1000) + " else { 2 }"
+	p := NewParser(lexer.NewLexer(input))
+	prog := p.ParseProgram()
+	is.Equal(len(p.Errors()), 0)
+	is.Equal(len(prog.Statements), 1)
+}
+
+func TestLogicalExpressionParsing(t *testing.T) {
+	is := is2.New(t)
+	input := `age >= 18 && (country == "PT" || vip)`
+	p := NewParser(lexer.NewLexer(input))
+	prog := p.ParseProgram()
+	is.Equal(len(p.Errors()), 0)
+
+	stmt := prog.Statements[0].(*ast.ExpressionStatement)
+	and, ok := stmt.Expression.(*ast.LogicalExpression)
+	is.True(ok)
+	is.Equal(and.Operator, "&&")
+	left, ok := and.Left.(*ast.InfixExpression)
+	is.True(ok)
+	is.Equal(left.Operator, ">=")
+
+	or, ok := and.Right.(*ast.LogicalExpression)
+	is.True(ok)
+	is.Equal(or.Operator, "||")
+	is.Equal(or.Left.String(), `(country == "PT")`)
+	is.Equal(or.Right.String(), "vip")
+}
+
+func TestConditionalAndCoalesceParsing(t *testing.T) {
+	is := is2.New(t)
+	p := NewParser(lexer.NewLexer(`let label = n > 1 ? "items" : name ?? "item";`))
+	prog := p.ParseProgram()
+	is.Equal(len(p.Errors()), 0)
+
+	let := prog.Statements[0].(*ast.LetStatement)
+	cond, ok := let.Value.(*ast.ConditionalExpression)
+	is.True(ok)
+	is.Equal(cond.Condition.String(), "(n > 1)")
+	is.Equal(cond.Consequence.String(), `"items"`)
+	coalesce, ok := cond.Alternative.(*ast.CoalesceExpression)
+	is.True(ok)
+	is.Equal(coalesce.Left.String(), "name")
+	is.Equal(coalesce.Right.String(), `"item"`)
+}
+
+func TestConditionalRoundTrip(t *testing.T) {
+	is := is2.New(t)
+	tests := []string{
+		"a ? b : c ? d : e",
+		"a ?? b ?? c ? d : e",
+		"x = ok ? {\"a\": 1 ? 2 : 3} : [a ?? b]",
+	}
+
+	for _, input := range tests {
+		p := NewParser(lexer.NewLexer(input))
+		prog := p.ParseProgram()
+		is.Equal(len(p.Errors()), 0)
+
+		again := NewParser(lexer.NewLexer(prog.String()))
+		is.Equal(again.ParseProgram().String(), prog.String())
+		is.Equal(len(again.Errors()), 0)
+	}
+}
+
+func TestPipeExpressionDesugar(t *testing.T) {
+	is := is2.New(t)
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x |> f", "f(x)"},
+		{"x |> f()", "f(x)"},
+		{"xs |> filter(isEven) |> map(double)", "map(filter(xs, isEven), double)"},
+		{"xs |> map(fn(x) { x * 2 }) |> join(\", \")", `join(map(xs, fn(x)(x * 2)), ", ")`},
+		{"x |> curry(1)(2)", "curry(1)(x, 2)"},
+		{"x |> fns[0]", "(fns[0])(x)"},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(lexer.NewLexer(tt.input))
+		prog := p.ParseProgram()
+		is.Equal(len(p.Errors()), 0)
+
+		stmt := prog.Statements[0].(*ast.ExpressionStatement)
+		pipe, ok := stmt.Expression.(*ast.PipeExpression)
+		is.True(ok)
+		is.Equal(pipe.Desugar().String(), tt.expected)
+	}
+}
+
+func TestFunctionParameters(t *testing.T) {
+	is := is2.New(t)
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"fn() { }", []string{}},
+		{"fn(x, y) { }", []string{"x", "y"}},
+		{"fn(x, y = 10) { }", []string{"x", "y = 10"}},
+		{"fn(x, y = 10, ...rest) { }", []string{"x", "y = 10", "...rest"}},
+		{"fn(...args) { }", []string{"...args"}},
+		{"fn(a = b ? 1 : 2, c = [1, 2],) { }", []string{"a = (b ? 1 : 2)", "c = [1, 2]"}},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(lexer.NewLexer(tt.input))
+		prog := p.ParseProgram()
+		is.Equal(len(p.Errors()), 0)
+
+		stmt := prog.Statements[0].(*ast.ExpressionStatement)
+		function, ok := stmt.Expression.(*ast.FunctionLiteral)
+		is.True(ok)
+
+		params := []string{}
+		for _, param := range function.Parameters {
+			params = append(params, param.String())
+		}
+		is.Equal(params, tt.expected)
+	}
+}
+
+func TestParameterShape(t *testing.T) {
+	is := is2.New(t)
+	p := NewParser(lexer.NewLexer("fn(x, y = 10, ...rest) { x }"))
+	prog := p.ParseProgram()
+	is.Equal(len(p.Errors()), 0)
+
+	function := prog.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	is.Equal(len(function.Parameters), 3)
+
+	x, y, rest := function.Parameters[0], function.Parameters[1], function.Parameters[2]
+	is.Equal(x.Name.Value, "x")
+	is.True(x.Default == nil)
+	is.True(!x.Variadic)
+	is.Equal(y.Name.Value, "y")
+	is.Equal(y.Default.String(), "10")
+	is.Equal(rest.Name.Value, "rest")
+	is.True(rest.Variadic)
+	is.Equal(rest.Token.Type, token.Type(token.ELLIPSIS))
+	is.Equal(function.String(), "fn(x, y = 10, ...rest)x")
+}
+
+func TestSpreadArguments(t *testing.T) {
+	is := is2.New(t)
+	p := NewParser(lexer.NewLexer("f(1, ...xs, ...g(y), z);"))
+	prog := p.ParseProgram()
+	is.Equal(len(p.Errors()), 0)
+
+	call := prog.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+	is.Equal(len(call.Arguments), 4)
+	spread, ok := call.Arguments[1].(*ast.SpreadExpression)
+	is.True(ok)
+	is.Equal(spread.Value.String(), "xs")
+	_, ok = call.Arguments[2].(*ast.SpreadExpression)
+	is.True(ok)
+	is.Equal(call.String(), "f(1, ...xs, ...g(y), z)")
+}
+
+func TestDestructuringLetStatements(t *testing.T) {
+	is := is2.New(t)
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let [a, b, ...rest] = xs;", "let [a, b, ...rest] = xs;"},
+		{"let {name, age: years} = person;", "let {name, age: years} = person;"},
+		{"let [a = 1, [b, c] = pair, {d}] = xs;", "let [a = 1, [b, c] = pair, {d}] = xs;"},
+		{`let {"first name": first, address: {city = "Lisbon"}, tags: [main, ...others],} = p;`,
+			`let {"first name": first, address: {city = "Lisbon"}, tags: [main, ...others]} = p;`},
+		{"let {x = a ? 1 : 2, y: z = 3} = h;", "let {x = (a ? 1 : 2), y: z = 3} = h;"},
+		{"let [] = xs;", "let [] = xs;"},
+	}
+
+	for _, tt := range tests {
+		p := NewParser(lexer.NewLexer(tt.input))
+		prog := p.ParseProgram()
+		is.Equal(len(p.Errors()), 0)
+		is.Equal(len(prog.Statements), 1)
+		is.Equal(prog.String(), tt.expected)
+	}
+}
+
+func TestPatternNodes(t *testing.T) {
+	is := is2.New(t)
+	p := NewParser(lexer.NewLexer(`let [first, {name, age: years = 0}, ...rest] = people;`))
+	prog := p.ParseProgram()
+	is.Equal(len(p.Errors()), 0)
+
+	let := prog.Statements[0].(*ast.LetStatement)
+	array, ok := let.Name.(*ast.ArrayPattern)
+	is.True(ok)
+	is.Equal(len(array.Elements), 3)
+
+	first, ok := array.Elements[0].(*ast.IdentifierStatement)
+	is.True(ok)
+	is.Equal(first.Value, "first")
+
+	hash, ok := array.Elements[1].(*ast.HashPattern)
+	is.True(ok)
+	is.Equal(len(hash.Pairs), 2)
+	is.Equal(hash.Pairs[0].Key.String(), "name")
+	is.Equal(hash.Pairs[0].Value.String(), "name")
+	is.Equal(hash.Pairs[1].Key.String(), "age")
+	years, ok := hash.Pairs[1].Value.(*ast.DefaultPattern)
+	is.True(ok)
+	is.Equal(years.Target.String(), "years")
+	is.Equal(years.Default.String(), "0")
+
+	rest, ok := array.Elements[2].(*ast.RestPattern)
+	is.True(ok)
+	is.Equal(rest.Target.Value, "rest")
+}
@@ -24,7 +24,7 @@ func Start(in io.Reader, out io.Writer){
 		line := scanner.Text()
 		lxr := lexer.NewLexer(line)
 		for tkn := lxr.NextToken(); tkn.Type != token.EOF; tkn = lxr.NextToken() {
-			fmt.Fprintf(out, "%+v\n", *tkn)
+			fmt.Fprintf(out, "{Type:%s Literal:%s}\n", tkn.Type, tkn.Literal)
 		}
 	}
 }
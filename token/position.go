@@ -0,0 +1,35 @@
+package token
+
+import "fmt"
+
+//Position describes a location inside a source file.
+//Lines and columns start at 1 while the offset is the
+//number of bytes from the beginning of the input.
+//A tab counts as a single column and a "\r\n" pair
+//counts as a single line break.
+type Position struct {
+	Filename string
+	Offset   int
+	Line     int
+	Column   int
+}
+
+//IsValid reports whether the position points somewhere in a file.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+//String representation of a position.
+//"file.mk:2:5", "2:5" when there is no file name or "-" when not valid.
+func (p Position) String() string {
+	if !p.IsValid() {
+		if p.Filename != "" {
+			return p.Filename
+		}
+		return "-"
+	}
+	if p.Filename == "" {
+		return fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+}
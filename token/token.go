@@ -7,10 +7,19 @@ that can be expressed as constants in our code.
 const (
 	ILLEGAL = "ILLEGAL"
 	EOF     = "EOF"
+	COMMENT = "COMMENT" // only returned when asked for
 
 	//identifiers
 	IDENTIFIER = "IDENTIFIER" // add, foobar, x, y, ...
 	INT        = "INT"   // 1343456
+	FLOAT      = "FLOAT" // 3.14
+	STRING     = "STRING" // "foobar"
+	RAW_STRING = "RAW_STRING" // `foo\bar`
+	RAW_BLOCK  = "RAW_BLOCK"  // ```foo\bar```
+	//"Hello ${name}, bye ${name}" is split in the following parts
+	TEMPLATE_HEAD   = "TEMPLATE_HEAD"   // "Hello ${
+	TEMPLATE_MIDDLE = "TEMPLATE_MIDDLE" // }, bye ${
+	TEMPLATE_TAIL   = "TEMPLATE_TAIL"   // }"
 	EXPRESSION = "EXPRESSION"
 
 	//operators
@@ -22,18 +31,37 @@ const (
 	BANG     = "!"
 	ASTERISK = "*"
 	SLASH    = "/"
+	PERCENT  = "%"
 	LT       = "<"
 	GT       = ">"
+	LT_EQUAL = "<="
+	GT_EQUAL = ">="
+	AND      = "&&"
+	OR       = "||"
+	QUESTION = "?"
+	COALESCE = "??"
+	PIPE     = "|>"
 	INCREMENT= "++"
 	DECREMENT= "--"
 
+	//compound assignments
+	PLUS_ASSIGN     = "+="
+	MINUS_ASSIGN    = "-="
+	ASTERISK_ASSIGN = "*="
+	SLASH_ASSIGN    = "/="
+
 	//delimiters
 	COMMA     = ","
 	SEMICOLON = ";"
+	COLON     = ":"
+	DOT       = "."
+	ELLIPSIS  = "..."
 	LPAREN    = "("
 	RPAREN    = ")"
 	LBRACE    = "{"
 	RBRACE    = "}"
+	LBRACKET  = "["
+	RBRACKET  = "]"
 
 	//keywords
 	FUNCTION = "FUNCTION"
@@ -43,39 +71,77 @@ const (
 	TRUE     = "TRUE"
 	FALSE    = "FALSE"
 	RETURN   = "RETURN"
+	WHILE    = "WHILE"
+	FOR      = "FOR"
+	IN       = "IN"
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
 )
 
 type Type string
 
 //Token represents one token passed to our lexer.
 //It has a type to distinguish tokens and also a value.
+//Start points to the first character of the token and End
+//to the position right after its last character.
 type Token struct {
 	Type    Type
 	Literal string
+	Start   Position
+	End     Position
 }
 
 //NewToken creates a new instance of type Token
-func NewToken(t Type, l byte) *Token {
-	return &Token{
+func NewToken(t Type, l rune) Token {
+	return Token{
 		Type:    t,
 		Literal: string(l),
 	}
 }
 
-var keywords = map[string]Type{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"if":     IF,
-	"else":   ELSE,
-	"true":   TRUE,
-	"false":  FALSE,
-	"return": RETURN,
+//Keywords maps the words reserved by a dialect of the language
+//to the type of their token. Words not found are identifiers.
+type Keywords map[string]Type
+
+var keywords = Keywords{
+	"fn":       FUNCTION,
+	"let":      LET,
+	"if":       IF,
+	"else":     ELSE,
+	"true":     TRUE,
+	"false":    FALSE,
+	"return":   RETURN,
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
 }
 
-//GetIdentifier check if given word is an identifier or a keyword.
-func GetIdentifier(ident string) Type {
-	if t, ok := keywords[ident]; ok {
+//DefaultKeywords returns a copy of the keywords of the language.
+//It can be changed freely to build a new dialect.
+func DefaultKeywords() Keywords {
+	return keywords.Copy()
+}
+
+//Copy returns a new table with the same keywords.
+func (k Keywords) Copy() Keywords {
+	c := make(Keywords, len(k))
+	for word, t := range k {
+		c[word] = t
+	}
+	return c
+}
+
+//Lookup check if given word is an identifier or one of the keywords.
+func (k Keywords) Lookup(ident string) Type {
+	if t, ok := k[ident]; ok {
 		return t
 	}
 	return IDENTIFIER
 }
+
+//GetIdentifier check if given word is an identifier or a keyword.
+func GetIdentifier(ident string) Type {
+	return keywords.Lookup(ident)
+}
@@ -13,3 +13,32 @@ func TestNewToken(t *testing.T) {
 	is.True(intToken.Type == INT)
 	is.True(intToken.Literal == "1")
 }
+
+func TestPositionString(t *testing.T) {
+	is := is2.New(t)
+	tests := []struct {
+		pos      Position
+		expected string
+	}{
+		{Position{Filename: "main.mk", Offset: 12, Line: 2, Column: 5}, "main.mk:2:5"},
+		{Position{Offset: 12, Line: 2, Column: 5}, "2:5"},
+		{Position{Filename: "main.mk"}, "main.mk"},
+		{Position{}, "-"},
+	}
+
+	for _, tt := range tests {
+		is.Equal(tt.pos.String(), tt.expected)
+	}
+}
+
+func TestDefaultKeywordsIsACopy(t *testing.T) {
+	is := is2.New(t)
+	kw := DefaultKeywords()
+	kw["si"] = IF
+	delete(kw, "fn")
+
+	is.Equal(kw.Lookup("si"), Type(IF))
+	is.Equal(kw.Lookup("fn"), Type(IDENTIFIER))
+	is.Equal(GetIdentifier("si"), Type(IDENTIFIER))
+	is.Equal(GetIdentifier("fn"), Type(FUNCTION))
+}